@@ -2,9 +2,8 @@ package main
 
 import (
 	"os"
-	"path"
 
-	log "github.com/Sirupsen/logrus"
+	log "github.com/sirupsen/logrus"
 
 	"github.com/Masterminds/semver"
 	"github.com/moolen/asdf/changelog"
@@ -13,62 +12,123 @@ import (
 )
 
 // changelog is a stateless command that, given a range,
-// will write the changelog to stdout
+// will write the changelog to stdout in the given format.
+// For a monorepo the changelog of every package is written.
+// With --all the changelog of every past release is written
 func changelogCommand(c *cli.Context) error {
-	var err error
-	var commits repository.Commits
-	var version *semver.Version
-	revision := c.String(flagRevision)
-	versionString := c.String(flagVersion)
-	versionFile := c.String(flagFile)
 	cwd, err := getCwd(c)
 	if err != nil {
 		return cli.NewExitError(err, 1)
 	}
-	versionPath := path.Join(cwd, versionFile)
-	repo := repository.New(cwd, repository.DefaultMapFunc)
+	cfg, err := loadConfig(c, cwd)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	render, err := cfg.newChangelog().Renderer(c.String(flagFormat))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	revision := c.String(flagRevision)
+	versionString := c.String(flagVersion)
+	packages := cfg.packageConfigs()
+	var releases []*changelog.Release
+	released := make(map[string]*semver.Version)
+	for _, pkgCfg := range packages {
+		if c.Bool(flagAll) {
+			history, err := releaseHistory(cwd, pkgCfg)
+			if err == errNoReleases && len(packages) > 1 {
+				log.Infof("skipping %s: %v", pkgCfg.packageName(), err)
+				continue
+			}
+			if err != nil {
+				return cli.NewExitError(err, 9)
+			}
+			releases = append(releases, history...)
+			continue
+		}
+		pkgCfg.updateDependencies(released)
+		release, version, code, err := packageChangelog(cwd, pkgCfg, revision, versionString)
+		if err == errNoCommits && len(packages) > 1 {
+			log.Infof("skipping %s: %v", pkgCfg.packageName(), err)
+			continue
+		}
+		if err != nil {
+			return cli.NewExitError(err, code)
+		}
+		released[pkgCfg.packageName()] = version
+		releases = append(releases, release)
+	}
+	out, err := render(releases)
+	if err != nil {
+		return cli.NewExitError(err, 8)
+	}
+	os.Stdout.WriteString(out)
+	return nil
+}
+
+// packageChangelog returns the release and the next version
+// or the error and its exit code
+func packageChangelog(cwd string, cfg *Config, revision, versionString string) (*changelog.Release, *semver.Version, int, error) {
+	var err error
+	var commits repository.Commits
+	var version *semver.Version
+	var since string
+	repo := cfg.newRepository(cwd)
 
 	// 2nd use-case: supply revision + version explicitly
 	if revision != "" && versionString != "" {
 		log.Infof("found revision %s and version %s", revision, versionString)
 		version, err = semver.NewVersion(versionString)
 		if err != nil {
-			return cli.NewExitError(errNoSemverVersion, 2)
+			return nil, nil, 2, errNoSemverVersion
 		}
 		commits, err = repo.GetHistory(revision)
-		log.Infof("found %d commits", len(commits))
 		if err != nil {
-			return cli.NewExitError(err, 3)
+			return nil, nil, 3, err
 		}
-	} else if versionFile != "" {
-		log.Infof("using version file %s", versionFile)
-		version, err = readVersionFile(versionPath)
-		if err != nil && !os.IsNotExist(err) {
-			return cli.NewExitError(err, 4)
+		commits = commits.WithoutReverted()
+		log.Infof("found %d commits", len(commits))
+	} else {
+		version, since, err = latestRelease(repo, cwd, cfg)
+		if err != nil {
+			return nil, nil, 4, err
 		}
-		commit, err := repo.LatestChangeOfFile(versionPath)
-		log.Infof("latest change: %s", commit.Hash)
+		since, err = historyStart(repo, cfg, version, since)
 		if err != nil {
-			return cli.NewExitError(err, 5)
+			return nil, nil, 4, err
 		}
-		commits, err = repo.GetHistoryUntil(commit.Hash)
+		commits, err = historySince(repo, since)
 		log.Infof("found %d commits", len(commits))
 		if err != nil {
-			return cli.NewExitError(err, 6)
+			return nil, nil, 6, err
 		}
 	}
 
-	if len(commits) == 0 {
-		return cli.NewExitError(errNoCommits, 5)
+	if len(commits) == 0 && len(cfg.updated) == 0 {
+		return nil, nil, 5, errNoCommits
+	}
+	cl := cfg.newChangelog()
+	cl.PreviousRef = since
+	nextVersion, err := nextVersion(repo, cfg, version, releaseChange(commits, cfg))
+	if err != nil {
+		return nil, nil, 7, err
+	}
+	release := cl.Release(commits, nextVersion)
+	release.Package = cfg.packageName()
+	return release, nextVersion, 0, nil
+}
+
+// templateFlag selects the template that renders the Markdown changelog
+func templateFlag() cli.Flag {
+	return cli.StringFlag{
+		Name:  flagTemplate,
+		Value: defaultTemplate,
+		Usage: "name of a built-in template or path of a Go text/template file that renders the markdown changelog",
 	}
-	cl := changelog.New(DefaultTypeMap, changelog.DefaultFormatFunc)
-	nextVersion := nextReleaseByChange(version, commits.MaxChange())
-	os.Stdout.WriteString(cl.Create(commits, &nextVersion))
-	return nil
 }
 
 func changelogFlags() []cli.Flag {
-	return []cli.Flag{
+	return append([]cli.Flag{
 		cli.StringFlag{
 			Name:  flagRevision,
 			Usage: "revision to calculate the diff. Works only together with --" + flagVersion,
@@ -78,10 +138,20 @@ func changelogFlags() []cli.Flag {
 			Value: "",
 			Usage: "set the release version explicitly works only in conjunction with --" + flagRevision,
 		},
+		cli.StringFlag{
+			Name:  flagFormat,
+			Value: changelog.FormatMarkdown,
+			Usage: "output format: markdown, json, yaml, html, asciidoc or text",
+		},
+		templateFlag(),
+		cli.BoolFlag{
+			Name:  flagAll,
+			Usage: "write the changelog of every release in the history instead of the next release",
+		},
 		cli.StringFlag{
 			Name:  flagFile,
 			Value: "VERSION",
 			Usage: "file to use to get the commit of last modification. That file must include the latest version",
 		},
-	}
+	}, releaseFlags()...)
 }
@@ -1,9 +1,10 @@
 package changelog
 
 import (
+	"bytes"
 	"fmt"
-	"sort"
-	"time"
+	"strings"
+	"text/template"
 
 	"github.com/Masterminds/semver"
 	"github.com/moolen/asdf/repository"
@@ -11,47 +12,114 @@ import (
 
 // FormatFunc is called for every commit and should
 // return a pretty formatted commit message
-// which will be used for the changelog generation
+// which will be used for the changelog generation.
+// It only applies to changelogs without a Template
 type FormatFunc func(*repository.Commit) string
 
+// BreakingType is the commit type whose section lists
+// the breaking change descriptions of all commits
+const BreakingType = "breaking"
+
+// OtherType is the type of the section that lists the commits
+// without a type and, if collapsed, the commits of unknown types
+const OtherType = "other"
+
+// DefaultOrder is the order of the sections of a new Changelog
+var DefaultOrder = []string{BreakingType, "feat", "fix", "perf", "revert", "refactor", "docs", "test", "chore", DependenciesType}
+
+// DependenciesType is the type of the section that lists
+// the updated Dependencies
+const DependenciesType = "dependencies"
+
+// Dependency is a package that was released
+// and is used by the package of the changelog
+type Dependency struct {
+	Name    string
+	Version *semver.Version
+}
+
 // Changelog is used to create pretty changelog documents
 // provide a TypeMap to group commit messages
-// or a FormatFunc to style the messages
+// or a FormatFunc to style the messages.
+// The BreakingFormatFunc styles the description of breaking changes.
+// Sections are rendered in the given Order, types that are not
+// part of the Order follow in alphabetical order and the OtherType comes last.
+// Commits of Hidden types are not listed. Commits without a type are listed
+// in the OtherType section, with CollapseUnknown the commits of types
+// that are not part of the TypeMap as well.
+// The Style is applied to every commit before it is formatted.
+// Dependencies are listed in the DependenciesType section.
+// If a Template is set, it renders the Markdown instead of the FormatFuncs,
+// so the FormatFuncs are only used by library code that leaves the Template nil.
+// The Links are used for the URLs of the release, the commits and the issues.
+// The release is compared from the PreviousRef to the tag
+// consisting of the TagPrefix and the new version
 type Changelog struct {
-	TypeMap    map[string]string
-	FormatFunc FormatFunc
+	TypeMap            map[string]string
+	FormatFunc         FormatFunc
+	BreakingFormatFunc FormatFunc
+	Order              []string
+	Hidden             []string
+	CollapseUnknown    bool
+	Style              Style
+	Dependencies       []Dependency
+	Template           *template.Template
+	Links              Links
+	PreviousRef        string
+	TagPrefix          string
 }
 
 // New creates a new Changelog struct
 func New(typeMap map[string]string, format FormatFunc) *Changelog {
 	return &Changelog{
-		TypeMap:    typeMap,
-		FormatFunc: format,
+		TypeMap:            typeMap,
+		FormatFunc:         format,
+		BreakingFormatFunc: DefaultBreakingFormatFunc,
+		Order:              DefaultOrder,
 	}
 }
 
 // Create returns a pretty changelog as a string given an array of commits
 // This uses the TypeMap to group the commits by type and
-// formats every commit with the FormatFunc
-func (c *Changelog) Create(commits []*repository.Commit, newVersion *semver.Version) string {
-	var result string
-	if newVersion != nil {
-		result += fmt.Sprintf("## %s (%s)\n\n", newVersion.String(), time.Now().UTC().Format("2006-01-02"))
-	}
+// renders the release with Markdown.
+// Breaking changes of other types are additionally listed in the BreakingType section
+func (c *Changelog) Create(commits []*repository.Commit, newVersion *semver.Version) (string, error) {
+	return c.Markdown(c.Release(commits, newVersion))
+}
 
-	typeGroup := make(map[string]string)
-	for _, commit := range commits {
-		typeGroup[commit.Type] += c.FormatFunc(commit)
+// UnreleasedTitle is the heading of the Unreleased release
+const UnreleasedTitle = "Unreleased"
+
+// Markdown renders the release with the Template. Without a Template
+// the FormatFunc is used for the commits and the BreakingFormatFunc
+// for the descriptions of breaking changes
+func (c *Changelog) Markdown(release *Release) (string, error) {
+	if c.Template != nil {
+		var buf bytes.Buffer
+		err := c.Template.Execute(&buf, release)
+		return buf.String(), err
 	}
-	for _, t := range getSortedKeys(&typeGroup) {
-		msg := typeGroup[t]
-		typeName, found := c.TypeMap[t]
-		if !found {
-			typeName = t
+	var result string
+	if release.Version != "" {
+		result += fmt.Sprintf("## %s (%s)\n\n", release.Version, release.Date)
+	} else if release.Unreleased {
+		result += fmt.Sprintf("## %s\n\n", UnreleasedTitle)
+	}
+	for _, section := range release.Sections {
+		var msg string
+		for _, entry := range section.Entries {
+			switch {
+			case entry.commit == nil:
+				msg += fmt.Sprintf("* %s\n", entry.Text())
+			case entry.description:
+				msg += c.BreakingFormatFunc(entry.commit)
+			default:
+				msg += c.FormatFunc(entry.commit)
+			}
 		}
-		result += fmt.Sprintf("#### %s\n\n%s\n", typeName, msg)
+		result += fmt.Sprintf("#### %s\n\n%s\n", section.Title, msg)
 	}
-	return result
+	return result, nil
 }
 
 // DefaultFormatFunc is used to format a commit message
@@ -62,6 +130,15 @@ func DefaultFormatFunc(c *repository.Commit) string {
 	return fmt.Sprintf("* %s (%s) \n", c.Subject, TrimSHA(c.Hash))
 }
 
+// DefaultBreakingFormatFunc is used to format the description of a breaking change
+func DefaultBreakingFormatFunc(c *repository.Commit) string {
+	description := strings.Replace(c.BreakingDescription, "\n", "\n  ", -1)
+	if c.Scope != "" {
+		return fmt.Sprintf("* %s [%s] (%s) \n", description, c.Scope, TrimSHA(c.Hash))
+	}
+	return fmt.Sprintf("* %s (%s) \n", description, TrimSHA(c.Hash))
+}
+
 // TrimSHA returns only the leading 8 characters of a commit hash
 func TrimSHA(sha string) string {
 	if len(sha) < 9 {
@@ -70,13 +147,32 @@ func TrimSHA(sha string) string {
 	return sha[:8]
 }
 
-func getSortedKeys(m *map[string]string) []string {
-	keys := make([]string, len(*m))
-	i := 0
-	for k := range *m {
-		keys[i] = k
-		i++
+// orderKeys moves the keys that are part of order to the front
+// the remaining keys keep their relative order, except for the
+// OtherType which is moved to the end
+func orderKeys(keys []string, order []string) []string {
+	ordered := make([]string, 0, len(keys))
+	seen := make(map[string]bool)
+	for _, o := range order {
+		for _, k := range keys {
+			if k == o && !seen[k] {
+				ordered = append(ordered, k)
+				seen[k] = true
+			}
+		}
+	}
+	other := false
+	for _, k := range keys {
+		if k == OtherType && !seen[k] {
+			other = true
+			continue
+		}
+		if !seen[k] {
+			ordered = append(ordered, k)
+		}
+	}
+	if other {
+		ordered = append(ordered, OtherType)
 	}
-	sort.Strings(keys)
-	return keys
+	return ordered
 }
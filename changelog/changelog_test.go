@@ -2,6 +2,7 @@ package changelog
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -12,16 +13,16 @@ import (
 
 var mylog = `## 2.1.3-rc123 (%s)
 
-#### 
-
-test2
-test3
-
 #### Foo
 
 test1
 test4
 
+#### Other
+
+test2
+test3
+
 `
 
 func TestChangelog(t *testing.T) {
@@ -50,13 +51,137 @@ func TestChangelog(t *testing.T) {
 		},
 	}
 	version := semver.MustParse("2.1.3-rc123")
-	log := cl.Create(commits, version)
+	log, _ := cl.Create(commits, version)
 	formattedLog := fmt.Sprintf(mylog, time.Now().Format("2006-01-02"))
 	if log != formattedLog {
 		t.Fatalf("changelog did not match\nexpected\n%#v\ngot\n%#v\n", formattedLog, log)
 	}
 }
 
+func TestChangelogOrder(t *testing.T) {
+	cl := New(map[string]string{}, func(commit *repository.Commit) string {
+		return fmt.Sprintf("%s\n", commit.Subject)
+	})
+	cl.Order = []string{"feat", "fix"}
+	commits := []*repository.Commit{
+		{Subject: "chore", Type: "chore"},
+		{Subject: "fix", Type: "fix"},
+		{Subject: "docs", Type: "docs"},
+		{Subject: "feat", Type: "feat"},
+	}
+	log, _ := cl.Create(commits, nil)
+	expected := "#### feat\n\nfeat\n\n#### fix\n\nfix\n\n#### chore\n\nchore\n\n#### docs\n\ndocs\n\n"
+	if log != expected {
+		t.Fatalf("changelog did not match\nexpected\n%#v\ngot\n%#v\n", expected, log)
+	}
+}
+
+func TestChangelogSections(t *testing.T) {
+	commits := []*repository.Commit{
+		{Subject: "untyped", Type: ""},
+		{Subject: "ci", Type: "ci"},
+		{Subject: "chore", Type: "chore", Breaking: true, BreakingDescription: "hidden but breaking"},
+		{Subject: "feat", Type: "feat"},
+		{Subject: "build", Type: "build"},
+		{Subject: "fix", Type: "fix"},
+	}
+	table := []struct {
+		order    []string
+		hidden   []string
+		collapse bool
+		expected string
+	}{
+		{
+			// default order, unknown types alphabetically, other last
+			order:    DefaultOrder,
+			expected: "breaking:chore feat:feat fix:fix chore:chore build:build ci:ci other:untyped",
+		},
+		{
+			order:    DefaultOrder,
+			hidden:   []string{"chore", "ci"},
+			expected: "breaking:chore feat:feat fix:fix build:build other:untyped",
+		},
+		{
+			order:    DefaultOrder,
+			collapse: true,
+			expected: "breaking:chore feat:feat fix:fix chore:chore other:untyped,ci,build",
+		},
+		{
+			order:    DefaultOrder,
+			hidden:   []string{"other", "breaking"},
+			collapse: true,
+			expected: "feat:feat fix:fix chore:chore",
+		},
+		{
+			order:    []string{"other", "fix"},
+			expected: "other:untyped fix:fix breaking:chore build:build chore:chore ci:ci feat:feat",
+		},
+	}
+	for i, row := range table {
+		cl := New(map[string]string{"feat": "Feature", "fix": "Bug Fixes", "chore": "Chores"}, DefaultFormatFunc)
+		cl.Order = row.order
+		cl.Hidden = row.hidden
+		cl.CollapseUnknown = row.collapse
+		var sections []string
+		for _, section := range cl.Release(commits, nil).Sections {
+			var subjects []string
+			for _, entry := range section.Entries {
+				subjects = append(subjects, entry.Subject)
+			}
+			sections = append(sections, section.Type+":"+strings.Join(subjects, ","))
+		}
+		if strings.Join(sections, " ") != row.expected {
+			t.Fatalf("[%d] expected\n%s\ngot\n%s", i, row.expected, strings.Join(sections, " "))
+		}
+	}
+	log, _ := New(nil, DefaultFormatFunc).Create([]*repository.Commit{{Subject: "untyped", Hash: "1234"}}, nil)
+	if log != "#### Other\n\n* untyped (1234) \n\n" {
+		t.Fatalf("unexpected changelog %#v", log)
+	}
+}
+
+func TestChangelogBreaking(t *testing.T) {
+	cl := New(map[string]string{"breaking": "Breaking Changes", "feat": "Feature"}, DefaultFormatFunc)
+	commits := []*repository.Commit{
+		{
+			Subject:             "new api",
+			Type:                "feat",
+			Hash:                "1234",
+			Breaking:            true,
+			BreakingDescription: "the old api is gone\nuse the new one",
+		},
+		{
+			Subject:  "drop v1",
+			Type:     "breaking",
+			Hash:     "5678",
+			Breaking: true,
+		},
+	}
+	log, _ := cl.Create(commits, nil)
+	expected := "#### Breaking Changes\n\n* the old api is gone\n  use the new one (1234) \n* drop v1 (5678) \n\n#### Feature\n\n* new api (1234) \n\n"
+	if log != expected {
+		t.Fatalf("changelog did not match\nexpected\n%#v\ngot\n%#v\n", expected, log)
+	}
+}
+
+func TestChangelogDependencies(t *testing.T) {
+	cl := New(map[string]string{"dependencies": "Dependencies updated", "fix": "Bug Fixes"}, DefaultFormatFunc)
+	cl.Dependencies = []Dependency{
+		{Name: "lib", Version: semver.MustParse("1.2.0")},
+		{Name: "svc-a/api", Version: semver.MustParse("0.3.1")},
+	}
+	log, _ := cl.Create([]*repository.Commit{{Subject: "bug", Type: "fix", Hash: "1234"}}, nil)
+	expected := "#### Bug Fixes\n\n* bug (1234) \n\n#### Dependencies updated\n\n* lib 1.2.0\n* svc-a/api 0.3.1\n\n"
+	if log != expected {
+		t.Fatalf("changelog did not match\nexpected\n%#v\ngot\n%#v\n", expected, log)
+	}
+	log, _ = cl.Create(nil, nil)
+	expected = "#### Dependencies updated\n\n* lib 1.2.0\n* svc-a/api 0.3.1\n\n"
+	if log != expected {
+		t.Fatalf("changelog did not match\nexpected\n%#v\ngot\n%#v\n", expected, log)
+	}
+}
+
 func TestTrim(t *testing.T) {
 	table := []struct {
 		in  string
@@ -0,0 +1,112 @@
+package changelog
+
+import (
+	"regexp"
+	"strings"
+)
+
+// DefaultMarker separates the preamble of a changelog file from the releases.
+// New releases are inserted after the marker
+const DefaultMarker = "<!-- releases -->"
+
+// sectionSeparator follows every release section of a changelog file
+const sectionSeparator = "\n\n\n"
+
+// releaseHeading matches a Markdown heading containing a semver version,
+// e.g. `## 1.2.3 (2017-11-14)` or `## [v1.2.3](https://...) (2017-11-14)`
+var releaseHeading = regexp.MustCompile(`^#+\s.*?\bv?(\d+\.\d+\.\d+(?:-[0-9A-Za-z.-]+)?(?:\+[0-9A-Za-z.-]+)?)`)
+
+// unreleasedHeading matches the heading of the Unreleased section,
+// e.g. `## Unreleased` or `## [Unreleased](https://...)`
+var unreleasedHeading = regexp.MustCompile(`(?i)^#+\s+\[?` + UnreleasedTitle + `\b`)
+
+// File is a changelog file split into the preamble and its release sections
+type File struct {
+	// Preamble is the text before the first release including the marker,
+	// e.g. a title and a description of the changelog
+	Preamble string
+	Sections []FileSection
+}
+
+// FileSection is the text of a release in a changelog file
+type FileSection struct {
+	// Version is the UnreleasedTitle for the Unreleased section
+	Version string
+	Text    string
+}
+
+// ParseFile splits the content of a changelog file into the preamble and the
+// release sections. A section starts with a heading containing its version
+// or the UnreleasedTitle.
+// If the content contains the marker line, everything up to the marker
+// belongs to the preamble
+func ParseFile(content, marker string) *File {
+	file := &File{}
+	lines := strings.SplitAfter(content, "\n")
+	start := 0
+	if marker != "" {
+		for i, line := range lines {
+			if strings.TrimSpace(line) == marker {
+				start = i + 1
+				break
+			}
+		}
+	}
+	var section *FileSection
+	for i, line := range lines {
+		if i >= start {
+			if unreleasedHeading.MatchString(line) {
+				file.Sections = append(file.Sections, FileSection{Version: UnreleasedTitle})
+				section = &file.Sections[len(file.Sections)-1]
+			} else if match := releaseHeading.FindStringSubmatch(line); match != nil {
+				file.Sections = append(file.Sections, FileSection{Version: match[1]})
+				section = &file.Sections[len(file.Sections)-1]
+			}
+		}
+		if section == nil {
+			file.Preamble += line
+		} else {
+			section.Text += line
+		}
+	}
+	return file
+}
+
+// Set replaces the section of the version with the text.
+// If there is no section of the version yet, it is inserted as the latest release
+func (f *File) Set(version, text string) {
+	section := FileSection{Version: strings.TrimPrefix(version, "v"), Text: text + sectionSeparator}
+	for i := range f.Sections {
+		if f.Sections[i].Version == section.Version {
+			f.Sections[i] = section
+			return
+		}
+	}
+	f.Sections = append([]FileSection{section}, f.Sections...)
+}
+
+// Remove removes the section of the version
+func (f *File) Remove(version string) {
+	version = strings.TrimPrefix(version, "v")
+	for i := range f.Sections {
+		if f.Sections[i].Version == version {
+			f.Sections = append(f.Sections[:i], f.Sections[i+1:]...)
+			return
+		}
+	}
+}
+
+// String returns the content of the changelog file.
+// The preamble is separated from the releases by an empty line
+func (f *File) String() string {
+	content := f.Preamble
+	if content != "" && len(f.Sections) > 0 {
+		for !strings.HasSuffix(content, "\n\n") {
+			content += "\n"
+		}
+	}
+	for _, section := range f.Sections {
+		content += section.Text
+	}
+	return content
+}
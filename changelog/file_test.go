@@ -0,0 +1,81 @@
+package changelog
+
+import (
+	"testing"
+)
+
+func TestFileSet(t *testing.T) {
+	section := "## 1.1.0 (2020-01-02)\n\n#### Feature\n\n* new \n\n"
+	table := []struct {
+		content string
+		marker  string
+		version string
+		result  string
+	}{
+		{
+			content: "",
+			version: "1.1.0",
+			result:  section + "\n\n\n",
+		},
+		{
+			content: "# Changelog\n",
+			version: "1.1.0",
+			result:  "# Changelog\n\n" + section + "\n\n\n",
+		},
+		{
+			content: "# Changelog\n\n## 1.0.0 (2019-01-01)\n\n* old\n",
+			version: "1.1.0",
+			result:  "# Changelog\n\n" + section + "\n\n\n## 1.0.0 (2019-01-01)\n\n* old\n",
+		},
+		{
+			// the section of the same version is replaced
+			content: "# Changelog\n\n## [1.1.0](https://x/compare/1.0.0...1.1.0) (2020-01-01)\n\n* outdated\n\n\n\n## 1.0.0 (2019-01-01)\n\n* old\n",
+			version: "1.1.0",
+			result:  "# Changelog\n\n" + section + "\n\n\n## 1.0.0 (2019-01-01)\n\n* old\n",
+		},
+		{
+			// headings of the preamble above the marker are no releases
+			content: "# Changelog\n\n## Version 2.0.0 is planned\n<!-- releases -->\n## v1.0.0\n",
+			marker:  DefaultMarker,
+			version: "1.1.0",
+			result:  "# Changelog\n\n## Version 2.0.0 is planned\n<!-- releases -->\n\n" + section + "\n\n\n## v1.0.0\n",
+		},
+		{
+			// the Unreleased section is no release of the version
+			content: "# Changelog\n\n## [Unreleased](https://x/compare/1.0.0...HEAD)\n\n* new\n\n\n\n## 1.0.0\n",
+			version: "1.1.0",
+			result:  "# Changelog\n\n" + section + "\n\n\n## [Unreleased](https://x/compare/1.0.0...HEAD)\n\n* new\n\n\n\n## 1.0.0\n",
+		},
+		{
+			content: "## 1.1.0-rc.1\n\n* rc\n",
+			version: "1.1.0",
+			result:  section + "\n\n\n## 1.1.0-rc.1\n\n* rc\n",
+		},
+	}
+	for i, row := range table {
+		file := ParseFile(row.content, row.marker)
+		file.Set(row.version, section)
+		result := file.String()
+		if result != row.result {
+			t.Fatalf("[%d] expected\n%q\ngot\n%q", i, row.result, result)
+		}
+		// setting the section again does not change the file
+		file = ParseFile(result, row.marker)
+		file.Set(row.version, section)
+		if file.String() != result {
+			t.Fatalf("[%d] not idempotent\n%q\ngot\n%q", i, result, file.String())
+		}
+	}
+}
+
+func TestFileRemove(t *testing.T) {
+	file := ParseFile("# Changelog\n\n## unreleased\n\n* new\n\n## v1.0.0\n\n* old\n", "")
+	if len(file.Sections) != 2 || file.Sections[0].Version != UnreleasedTitle || file.Sections[1].Version != "1.0.0" {
+		t.Fatalf("unexpected sections %#v", file.Sections)
+	}
+	file.Remove(UnreleasedTitle)
+	file.Remove("2.0.0")
+	if result := file.String(); result != "# Changelog\n\n## v1.0.0\n\n* old\n" {
+		t.Fatalf("unexpected file %q", result)
+	}
+}
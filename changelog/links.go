@@ -0,0 +1,119 @@
+package changelog
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Tokens that are replaced in the URL patterns of Links
+const (
+	// HashToken is replaced with the full commit hash
+	HashToken = "{HASH}"
+	// PreviousToken is replaced with the tag or commit of the previous release
+	PreviousToken = "{PREVIOUS}"
+	// CurrentToken is replaced with the tag of the new release
+	CurrentToken = "{CURRENT}"
+	// IDToken is replaced with the issue number or the ticket id
+	IDToken = "{ID}"
+)
+
+// Links contains the URL patterns used to link
+// commits, releases and issues. Empty patterns are not linked
+type Links struct {
+	// Commit is the URL of a commit, e.g. https://github.com/o/r/commit/{HASH}
+	Commit string
+	// Compare is the URL of the changes between two releases,
+	// e.g. https://github.com/o/r/compare/{PREVIOUS}...{CURRENT}
+	Compare string
+	// Issue is the URL of a `#123` reference, e.g. https://github.com/o/r/issues/{ID}
+	Issue string
+	// Ticket is the URL of a `PROJ-123` reference, e.g. https://jira.example.com/browse/{ID}
+	Ticket string
+}
+
+// Issue is a reference to an issue or ticket
+type Issue struct {
+	ID  string `json:"id" yaml:"id"`
+	URL string `json:"url,omitempty" yaml:"url,omitempty"`
+}
+
+var issuePattern = regexp.MustCompile(`(?:^|[^\w&/])(#\d+)\b`)
+
+var ticketPattern = regexp.MustCompile(`\b([A-Z][A-Z0-9]+-\d+)\b`)
+
+// closingFooters are the footer tokens whose references are closed by a commit
+var closingFooters = []string{"closes", "close", "fixes", "fix", "resolves", "resolve"}
+
+// issues returns the references of the texts
+func (l Links) issues(texts ...string) []Issue {
+	var issues []Issue
+	seen := make(map[string]bool)
+	for _, text := range texts {
+		for _, found := range issuePattern.FindAllStringSubmatch(text, -1) {
+			if !seen[found[1]] {
+				seen[found[1]] = true
+				issues = append(issues, Issue{ID: found[1], URL: expand(l.Issue, IDToken, strings.TrimPrefix(found[1], "#"))})
+			}
+		}
+		for _, found := range ticketPattern.FindAllStringSubmatch(text, -1) {
+			if !seen[found[1]] {
+				seen[found[1]] = true
+				issues = append(issues, Issue{ID: found[1], URL: expand(l.Ticket, IDToken, found[1])})
+			}
+		}
+	}
+	return issues
+}
+
+// closes returns the references of the closing footers
+// that are not part of the given issues
+func (l Links) closes(entry Entry, issues []Issue) []Issue {
+	var values []string
+	for _, footer := range entry.Footers {
+		for _, token := range closingFooters {
+			if strings.EqualFold(footer.Token, token) {
+				values = append(values, footer.Value)
+			}
+		}
+	}
+	var closes []Issue
+	for _, issue := range l.issues(values...) {
+		if !containsIssue(issues, issue.ID) {
+			closes = append(closes, issue)
+		}
+	}
+	return closes
+}
+
+func containsIssue(issues []Issue, id string) bool {
+	for _, issue := range issues {
+		if issue.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// expand replaces the token of the pattern.
+// An empty pattern stays empty
+func expand(pattern, token, value string) string {
+	if pattern == "" {
+		return ""
+	}
+	return strings.Replace(pattern, token, value, -1)
+}
+
+// linkIssues replaces the references in the text with Markdown links
+func linkIssues(issues []Issue, text string) string {
+	for _, issue := range issues {
+		if issue.URL == "" {
+			continue
+		}
+		pattern := regexp.MustCompile(`(^|[^\w\[])` + regexp.QuoteMeta(issue.ID) + `\b`)
+		link := "[" + issue.ID + "](" + issue.URL + ")"
+		text = pattern.ReplaceAllStringFunc(text, func(match string) string {
+			return strings.TrimSuffix(match, issue.ID) + link
+		})
+	}
+	return text
+}
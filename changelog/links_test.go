@@ -0,0 +1,93 @@
+package changelog
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/moolen/asdf/repository"
+)
+
+func TestIssues(t *testing.T) {
+	links := Links{Issue: "https://github.com/o/r/issues/{ID}", Ticket: "https://jira/browse/{ID}"}
+	table := []struct {
+		texts  []string
+		issues []Issue
+	}{
+		{
+			texts: []string{"fix #12 and #13, not a&#14 or o/r#15", "PROJ-123"},
+			issues: []Issue{
+				{ID: "#12", URL: "https://github.com/o/r/issues/12"},
+				{ID: "#13", URL: "https://github.com/o/r/issues/13"},
+				{ID: "PROJ-123", URL: "https://jira/browse/PROJ-123"},
+			},
+		},
+		{
+			texts:  []string{"#12 twice #12"},
+			issues: []Issue{{ID: "#12", URL: "https://github.com/o/r/issues/12"}},
+		},
+		{
+			texts: []string{"utf-8 and Proj-1 are no tickets"},
+		},
+	}
+	for i, row := range table {
+		issues := links.issues(row.texts...)
+		if !reflect.DeepEqual(issues, row.issues) {
+			t.Fatalf("[%d] expected %#v, got %#v", i, row.issues, issues)
+		}
+	}
+	if issues := (Links{}).issues("#12"); !reflect.DeepEqual(issues, []Issue{{ID: "#12"}}) {
+		t.Fatalf("unexpected issues without links %#v", issues)
+	}
+}
+
+func TestLinkedChangelog(t *testing.T) {
+	tmpl, _ := ParseTemplate("default", DefaultTemplate)
+	cl := New(map[string]string{"feat": "Feature"}, DefaultFormatFunc)
+	cl.Template = tmpl
+	cl.Links = Links{
+		Commit:  "https://github.com/o/r/commit/{HASH}",
+		Compare: "https://github.com/o/r/compare/{PREVIOUS}...{CURRENT}",
+		Issue:   "https://github.com/o/r/issues/{ID}",
+		Ticket:  "https://jira/browse/{ID}",
+	}
+	cl.PreviousRef = "v1.0.0"
+	cl.TagPrefix = "v"
+	commits := []*repository.Commit{
+		{
+			Hash:    "1234567890",
+			Type:    "feat",
+			Scope:   "PROJ-7",
+			Subject: "add login, see #12",
+			Footers: []repository.Footer{{Token: "Closes", Value: "#12"}, {Token: "Fixes", Value: "#13"}},
+		},
+	}
+	release := cl.Release(commits, semver.MustParse("1.1.0"))
+	release.Date = "2026-10-18"
+	out, err := cl.Markdown(release)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "## [1.1.0](https://github.com/o/r/compare/v1.0.0...v1.1.0) (2026-10-18)\n\n" +
+		"#### Feature\n\n" +
+		"* add login, see [#12](https://github.com/o/r/issues/12) [[PROJ-7](https://jira/browse/PROJ-7)], closes [#13](https://github.com/o/r/issues/13) ([12345678](https://github.com/o/r/commit/1234567890)) \n\n"
+	if out != expected {
+		t.Fatalf("expected\n%s\ngot\n%s", expected, out)
+	}
+
+	// the unreleased commits are compared to HEAD
+	out, _ = cl.Markdown(cl.Unreleased(commits))
+	if !strings.HasPrefix(out, "## [Unreleased](https://github.com/o/r/compare/v1.0.0...HEAD)\n\n#### Feature\n\n") {
+		t.Fatalf("unexpected unreleased release\n%s", out)
+	}
+
+	// without links only the closed issues are added
+	cl.Links = Links{}
+	release = cl.Release(commits, nil)
+	out, _ = cl.Markdown(release)
+	expected = "#### Feature\n\n* add login, see #12 [PROJ-7], closes #13 (12345678) \n\n"
+	if out != expected {
+		t.Fatalf("expected\n%s\ngot\n%s", expected, out)
+	}
+}
@@ -0,0 +1,189 @@
+package changelog
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver"
+	"github.com/moolen/asdf/repository"
+)
+
+// Release is the structured representation of a changelog
+// for a version. It is rendered by a RenderFunc
+type Release struct {
+	// Package is the name of the package in a monorepo
+	Package string `json:"package,omitempty" yaml:"package,omitempty"`
+	// Version is empty if the changelog is created without a version
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+	// Unreleased is set for the commits since the latest release, see Unreleased
+	Unreleased bool   `json:"unreleased,omitempty" yaml:"unreleased,omitempty"`
+	Date       string `json:"date" yaml:"date"`
+	// CompareURL links the changes since the previous release
+	CompareURL string    `json:"compare_url,omitempty" yaml:"compare_url,omitempty"`
+	Sections   []Section `json:"sections" yaml:"sections"`
+}
+
+// Section groups the entries of a commit type
+type Section struct {
+	Type    string  `json:"type" yaml:"type"`
+	Title   string  `json:"title" yaml:"title"`
+	Entries []Entry `json:"entries" yaml:"entries"`
+}
+
+// Entry is a commit of a release. Entries of the DependenciesType
+// section describe an updated dependency by Package and Version instead
+type Entry struct {
+	Hash                string              `json:"hash,omitempty" yaml:"hash,omitempty"`
+	URL                 string              `json:"url,omitempty" yaml:"url,omitempty"`
+	Author              string              `json:"author,omitempty" yaml:"author,omitempty"`
+	Email               string              `json:"email,omitempty" yaml:"email,omitempty"`
+	Type                string              `json:"type,omitempty" yaml:"type,omitempty"`
+	Scope               string              `json:"scope,omitempty" yaml:"scope,omitempty"`
+	Subject             string              `json:"subject,omitempty" yaml:"subject,omitempty"`
+	Body                string              `json:"body,omitempty" yaml:"body,omitempty"`
+	Breaking            bool                `json:"breaking,omitempty" yaml:"breaking,omitempty"`
+	BreakingDescription string              `json:"breaking_description,omitempty" yaml:"breaking_description,omitempty"`
+	Footers             []repository.Footer `json:"footers,omitempty" yaml:"footers,omitempty"`
+	// Issues are referenced by the subject or scope
+	Issues []Issue `json:"issues,omitempty" yaml:"issues,omitempty"`
+	// Closes are referenced by closing footers like `Closes: #12`
+	Closes []Issue `json:"closes,omitempty" yaml:"closes,omitempty"`
+	// PullRequest is the number of the merged pull request
+	PullRequest int    `json:"pull_request,omitempty" yaml:"pull_request,omitempty"`
+	Package     string `json:"package,omitempty" yaml:"package,omitempty"`
+	Version     string `json:"version,omitempty" yaml:"version,omitempty"`
+
+	commit *repository.Commit
+	// description is set for the BreakingDescription of a commit
+	// that is not of the BreakingType
+	description bool
+}
+
+// Text returns the text of the entry: the subject of a commit,
+// the description of a breaking change or the updated dependency
+func (e Entry) Text() string {
+	if e.Package != "" {
+		return e.Package + " " + e.Version
+	}
+	if e.description {
+		return e.BreakingDescription
+	}
+	return e.Subject
+}
+
+// Release groups the commits into sections by their type.
+// The Style is applied to every commit, breaking changes of other
+// types are additionally listed in the BreakingType section
+// and the Dependencies in the DependenciesType section.
+// Breaking changes of hidden types are listed nevertheless
+func (c *Changelog) Release(commits []*repository.Commit, newVersion *semver.Version) *Release {
+	release := &Release{
+		Date: time.Now().UTC().Format("2006-01-02"),
+	}
+	if newVersion != nil {
+		release.Version = newVersion.String()
+		release.CompareURL = c.compareURL(c.TagPrefix + release.Version)
+	}
+	typeGroup := make(map[string][]Entry)
+	for _, commit := range commits {
+		commit = c.Style.Apply(commit)
+		if commit.BreakingDescription != "" && commit.Type != BreakingType && !c.hidden(BreakingType) {
+			entry := c.newEntry(commit)
+			entry.description = true
+			typeGroup[BreakingType] = append(typeGroup[BreakingType], entry)
+		}
+		t := c.sectionType(commit.Type)
+		if c.hidden(commit.Type) || c.hidden(t) {
+			continue
+		}
+		typeGroup[t] = append(typeGroup[t], c.newEntry(commit))
+	}
+	if !c.hidden(DependenciesType) {
+		for _, dependency := range c.Dependencies {
+			typeGroup[DependenciesType] = append(typeGroup[DependenciesType], Entry{
+				Package: dependency.Name,
+				Version: dependency.Version.String(),
+			})
+		}
+	}
+	var keys []string
+	for t := range typeGroup {
+		keys = append(keys, t)
+	}
+	sort.Strings(keys)
+	for _, t := range orderKeys(keys, c.Order) {
+		title, found := c.TypeMap[t]
+		if !found && t == OtherType {
+			title = "Other"
+		} else if !found {
+			title = t
+		}
+		release.Sections = append(release.Sections, Section{
+			Type:    t,
+			Title:   title,
+			Entries: typeGroup[t],
+		})
+	}
+	return release
+}
+
+// Unreleased groups the commits that are not released yet like Release.
+// The release is compared from the PreviousRef to HEAD
+func (c *Changelog) Unreleased(commits []*repository.Commit) *Release {
+	release := c.Release(commits, nil)
+	release.Unreleased = true
+	release.CompareURL = c.compareURL("HEAD")
+	return release
+}
+
+// compareURL returns the URL of the changes from the PreviousRef to the current ref
+func (c *Changelog) compareURL(current string) string {
+	if c.PreviousRef == "" {
+		return ""
+	}
+	return strings.Replace(expand(c.Links.Compare, PreviousToken, c.PreviousRef), CurrentToken, current, -1)
+}
+
+// sectionType returns the type of the section that lists a commit of the given type
+func (c *Changelog) sectionType(t string) string {
+	if t == "" {
+		return OtherType
+	}
+	if _, known := c.TypeMap[t]; !known && c.CollapseUnknown && t != BreakingType {
+		return OtherType
+	}
+	return t
+}
+
+// hidden is true if the commits of the type are not listed
+func (c *Changelog) hidden(t string) bool {
+	for _, hidden := range c.Hidden {
+		if hidden == t {
+			return true
+		}
+	}
+	return false
+}
+
+// newEntry creates the entry of a commit with its links
+func (c *Changelog) newEntry(commit *repository.Commit) Entry {
+	entry := Entry{
+		Hash:                commit.Hash,
+		Author:              commit.Author.Name,
+		Email:               commit.Author.Email,
+		Type:                commit.Type,
+		Scope:               commit.Scope,
+		Subject:             commit.Subject,
+		Body:                strings.TrimSpace(commit.Body),
+		Breaking:            commit.Breaking,
+		BreakingDescription: commit.BreakingDescription,
+		Footers:             commit.Footers,
+		PullRequest:         commit.PullRequest,
+		commit:              commit,
+	}
+	entry.URL = expand(c.Links.Commit, HashToken, commit.Hash)
+	entry.Issues = c.Links.issues(commit.Subject, commit.Scope)
+	entry.Closes = c.Links.closes(entry, entry.Issues)
+	return entry
+}
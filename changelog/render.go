@@ -0,0 +1,199 @@
+package changelog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Formats of the RenderFuncs returned by Renderer
+const (
+	FormatMarkdown = "markdown"
+	FormatJSON     = "json"
+	FormatYAML     = "yaml"
+	FormatHTML     = "html"
+	FormatAsciiDoc = "asciidoc"
+	FormatText     = "text"
+)
+
+// ErrUnknownFormat is returned if there is no renderer for a format
+var ErrUnknownFormat = errors.New("unknown format: expected markdown, json, yaml, html, asciidoc or text")
+
+// RenderFunc renders releases, e.g. the releases of all packages of a monorepo
+type RenderFunc func(releases []*Release) (string, error)
+
+// Renderer returns the RenderFunc for the format.
+// Markdown is rendered like Changelog.Markdown, with the Template if it is set
+func (c *Changelog) Renderer(format string) (RenderFunc, error) {
+	switch format {
+	case FormatMarkdown:
+		return c.renderMarkdown, nil
+	case FormatJSON:
+		return RenderJSON, nil
+	case FormatYAML:
+		return RenderYAML, nil
+	case FormatHTML:
+		return RenderHTML, nil
+	case FormatAsciiDoc:
+		return RenderAsciiDoc, nil
+	case FormatText:
+		return RenderText, nil
+	}
+	return nil, ErrUnknownFormat
+}
+
+// renderMarkdown renders every release with Markdown.
+// If there are several releases, the releases of a package
+// start with a header containing the package
+func (c *Changelog) renderMarkdown(releases []*Release) (string, error) {
+	var result string
+	for i, release := range releases {
+		if i > 0 {
+			result += "\n"
+		}
+		if release.Package != "" && len(releases) > 1 && (i == 0 || releases[i-1].Package != release.Package) {
+			result += fmt.Sprintf("# %s\n\n", release.Package)
+		}
+		markdown, err := c.Markdown(release)
+		if err != nil {
+			return "", err
+		}
+		result += markdown
+	}
+	return result, nil
+}
+
+// RenderJSON renders the releases as a JSON array
+func RenderJSON(releases []*Release) (string, error) {
+	if releases == nil {
+		releases = []*Release{}
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	err := encoder.Encode(releases)
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// RenderYAML renders the releases as a YAML sequence
+func RenderYAML(releases []*Release) (string, error) {
+	if releases == nil {
+		releases = []*Release{}
+	}
+	out, err := yaml.Marshal(releases)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// RenderHTML renders the releases as an HTML fragment
+func RenderHTML(releases []*Release) (string, error) {
+	var result string
+	for _, release := range releases {
+		result += "<section class=\"release\">\n"
+		if release.Package != "" {
+			result += fmt.Sprintf("<h1>%s</h1>\n", html.EscapeString(release.Package))
+		}
+		if release.Version != "" {
+			result += fmt.Sprintf("<h2>%s <small>(%s)</small></h2>\n", htmlLink(release.Version, release.CompareURL), release.Date)
+		}
+		for _, section := range release.Sections {
+			result += fmt.Sprintf("<h4>%s</h4>\n<ul>\n", html.EscapeString(section.Title))
+			for _, entry := range section.Entries {
+				result += "  <li>" + html.EscapeString(entry.Text())
+				if entry.Scope != "" {
+					result += fmt.Sprintf(" <em>[%s]</em>", html.EscapeString(entry.Scope))
+				}
+				if entry.Hash != "" {
+					result += fmt.Sprintf(" (<code>%s</code>)", htmlLink(TrimSHA(entry.Hash), entry.URL))
+				}
+				result += "</li>\n"
+			}
+			result += "</ul>\n"
+		}
+		result += "</section>\n"
+	}
+	return result, nil
+}
+
+// RenderAsciiDoc renders the releases as AsciiDoc
+func RenderAsciiDoc(releases []*Release) (string, error) {
+	var result string
+	for _, release := range releases {
+		if release.Package != "" {
+			result += fmt.Sprintf("= %s\n\n", release.Package)
+		}
+		if release.Version != "" {
+			result += fmt.Sprintf("== %s (%s)\n\n", asciiDocLink(release.Version, release.CompareURL), release.Date)
+		}
+		for _, section := range release.Sections {
+			result += fmt.Sprintf("=== %s\n\n", section.Title)
+			for _, entry := range section.Entries {
+				result += "* " + strings.Replace(entry.Text(), "\n", " +\n", -1)
+				if entry.Scope != "" {
+					result += fmt.Sprintf(" _[%s]_", entry.Scope)
+				}
+				if entry.Hash != "" {
+					result += fmt.Sprintf(" (%s)", asciiDocLink("`"+TrimSHA(entry.Hash)+"`", entry.URL))
+				}
+				result += "\n"
+			}
+			result += "\n"
+		}
+	}
+	return result, nil
+}
+
+// RenderText renders the releases as plain text
+func RenderText(releases []*Release) (string, error) {
+	var result string
+	for _, release := range releases {
+		if release.Package != "" {
+			result += fmt.Sprintf("%s\n\n", release.Package)
+		}
+		if release.Version != "" {
+			result += fmt.Sprintf("%s (%s)\n\n", release.Version, release.Date)
+		}
+		for _, section := range release.Sections {
+			result += fmt.Sprintf("%s:\n", section.Title)
+			for _, entry := range section.Entries {
+				result += "  - " + strings.Replace(entry.Text(), "\n", "\n    ", -1)
+				if entry.Scope != "" {
+					result += fmt.Sprintf(" [%s]", entry.Scope)
+				}
+				if entry.Hash != "" {
+					result += fmt.Sprintf(" (%s)", TrimSHA(entry.Hash))
+				}
+				result += "\n"
+			}
+			result += "\n"
+		}
+	}
+	return result, nil
+}
+
+// htmlLink returns an anchor or the escaped text if the url is empty
+func htmlLink(text, url string) string {
+	if url == "" {
+		return html.EscapeString(text)
+	}
+	return fmt.Sprintf("<a href=\"%s\">%s</a>", html.EscapeString(url), html.EscapeString(text))
+}
+
+// asciiDocLink returns a link macro or the text if the url is empty
+func asciiDocLink(text, url string) string {
+	if url == "" {
+		return text
+	}
+	return fmt.Sprintf("link:%s[%s]", url, text)
+}
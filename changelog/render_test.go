@@ -0,0 +1,151 @@
+package changelog
+
+import (
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/moolen/asdf/repository"
+)
+
+func TestRenderers(t *testing.T) {
+	cl := New(map[string]string{"feat": "Feature", "breaking": "Breaking Changes"}, DefaultFormatFunc)
+	cl.Dependencies = []Dependency{{Name: "lib", Version: semver.MustParse("1.2.0")}}
+	release := cl.Release([]*repository.Commit{
+		{
+			Hash:                "1234567890",
+			Type:                "feat",
+			Scope:               "api",
+			Subject:             "new <api>",
+			Breaking:            true,
+			BreakingDescription: "old api removed",
+			Author:              repository.CommitAuthor{Name: "Jane", Email: "jane@example.com"},
+		},
+	}, semver.MustParse("2.0.0"))
+	release.Date = "2026-10-18"
+	table := []struct {
+		format   string
+		expected string
+	}{
+		{
+			format:   FormatMarkdown,
+			expected: "## 2.0.0 (2026-10-18)\n\n#### Breaking Changes\n\n* old api removed [api] (12345678) \n\n#### Feature\n\n* new <api> [api] (12345678) \n\n#### dependencies\n\n* lib 1.2.0\n\n",
+		},
+		{
+			format: FormatJSON,
+			expected: `[
+  {
+    "version": "2.0.0",
+    "date": "2026-10-18",
+    "sections": [
+      {
+        "type": "breaking",
+        "title": "Breaking Changes",
+        "entries": [
+          {
+            "hash": "1234567890",
+            "author": "Jane",
+            "email": "jane@example.com",
+            "type": "feat",
+            "scope": "api",
+            "subject": "new <api>",
+            "breaking": true,
+            "breaking_description": "old api removed"
+          }
+        ]
+      },
+      {
+        "type": "feat",
+        "title": "Feature",
+        "entries": [
+          {
+            "hash": "1234567890",
+            "author": "Jane",
+            "email": "jane@example.com",
+            "type": "feat",
+            "scope": "api",
+            "subject": "new <api>",
+            "breaking": true,
+            "breaking_description": "old api removed"
+          }
+        ]
+      },
+      {
+        "type": "dependencies",
+        "title": "dependencies",
+        "entries": [
+          {
+            "package": "lib",
+            "version": "1.2.0"
+          }
+        ]
+      }
+    ]
+  }
+]
+`,
+		},
+		{
+			format: FormatYAML,
+			expected: `- version: 2.0.0
+  date: "2026-10-18"
+  sections:
+  - type: breaking
+    title: Breaking Changes
+    entries:
+    - hash: "1234567890"
+      author: Jane
+      email: jane@example.com
+      type: feat
+      scope: api
+      subject: new <api>
+      breaking: true
+      breaking_description: old api removed
+  - type: feat
+    title: Feature
+    entries:
+    - hash: "1234567890"
+      author: Jane
+      email: jane@example.com
+      type: feat
+      scope: api
+      subject: new <api>
+      breaking: true
+      breaking_description: old api removed
+  - type: dependencies
+    title: dependencies
+    entries:
+    - package: lib
+      version: 1.2.0
+`,
+		},
+		{
+			format:   FormatHTML,
+			expected: "<section class=\"release\">\n<h2>2.0.0 <small>(2026-10-18)</small></h2>\n<h4>Breaking Changes</h4>\n<ul>\n  <li>old api removed <em>[api]</em> (<code>12345678</code>)</li>\n</ul>\n<h4>Feature</h4>\n<ul>\n  <li>new &lt;api&gt; <em>[api]</em> (<code>12345678</code>)</li>\n</ul>\n<h4>dependencies</h4>\n<ul>\n  <li>lib 1.2.0</li>\n</ul>\n</section>\n",
+		},
+		{
+			format:   FormatAsciiDoc,
+			expected: "== 2.0.0 (2026-10-18)\n\n=== Breaking Changes\n\n* old api removed _[api]_ (`12345678`)\n\n=== Feature\n\n* new <api> _[api]_ (`12345678`)\n\n=== dependencies\n\n* lib 1.2.0\n\n",
+		},
+		{
+			format:   FormatText,
+			expected: "2.0.0 (2026-10-18)\n\nBreaking Changes:\n  - old api removed [api] (12345678)\n\nFeature:\n  - new <api> [api] (12345678)\n\ndependencies:\n  - lib 1.2.0\n\n",
+		},
+	}
+	for i, row := range table {
+		render, err := cl.Renderer(row.format)
+		if err != nil {
+			t.Fatalf("[%d] unexpected error: %v", i, err)
+		}
+		out, err := render([]*Release{release})
+		if err != nil {
+			t.Fatalf("[%d] unexpected error: %v", i, err)
+		}
+		if out != row.expected {
+			t.Fatalf("[%d] %s did not match\nexpected\n%s\ngot\n%s", i, row.format, row.expected, out)
+		}
+	}
+	_, err := cl.Renderer("pdf")
+	if err != ErrUnknownFormat {
+		t.Fatalf("expected ErrUnknownFormat, got %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package changelog
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/moolen/asdf/repository"
+)
+
+// Case defines how the case of a text is normalised
+type Case string
+
+const (
+	// KeepCase does not change the text
+	KeepCase Case = ""
+	// LowerCase converts the text to lower case
+	LowerCase Case = "lower"
+	// UpperCase converts the text to upper case
+	UpperCase Case = "upper"
+	// SentenceCase converts the first letter to upper case
+	SentenceCase Case = "sentence"
+)
+
+// ErrUnknownCase is returned if a string does not represent a Case
+var ErrUnknownCase = errors.New("unknown case: expected lower, upper or sentence")
+
+// ParseCase returns the Case represented by the given string
+func ParseCase(s string) (Case, error) {
+	switch c := Case(strings.ToLower(s)); c {
+	case KeepCase, LowerCase, UpperCase, SentenceCase:
+		return c, nil
+	}
+	return KeepCase, ErrUnknownCase
+}
+
+// Apply returns the text in the given case
+func (c Case) Apply(text string) string {
+	switch c {
+	case LowerCase:
+		return strings.ToLower(text)
+	case UpperCase:
+		return strings.ToUpper(text)
+	case SentenceCase:
+		if text == "" {
+			return text
+		}
+		r, size := utf8.DecodeRuneInString(text)
+		return string(unicode.ToUpper(r)) + text[size:]
+	}
+	return text
+}
+
+// Style defines how subjects and scopes of commits are presented
+// in the changelog. The zero value keeps them as they are
+type Style struct {
+	SubjectCase Case
+	ScopeCase   Case
+	// MaxSubjectLength truncates subjects that are longer.
+	// Zero disables truncation
+	MaxSubjectLength int
+	// Ellipsis is appended to truncated subjects
+	Ellipsis string
+}
+
+// Apply returns a copy of the commit with subject and scope normalised.
+// The commit itself is not modified
+func (s Style) Apply(commit *repository.Commit) *repository.Commit {
+	styled := *commit
+	styled.Subject = s.SubjectCase.Apply(styled.Subject)
+	styled.Scope = s.ScopeCase.Apply(styled.Scope)
+	if s.MaxSubjectLength > 0 && utf8.RuneCountInString(styled.Subject) > s.MaxSubjectLength {
+		styled.Subject = string([]rune(styled.Subject)[:s.MaxSubjectLength]) + s.Ellipsis
+	}
+	return &styled
+}
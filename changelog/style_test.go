@@ -0,0 +1,74 @@
+package changelog
+
+import (
+	"testing"
+
+	"github.com/moolen/asdf/repository"
+)
+
+func TestStyle(t *testing.T) {
+	table := []struct {
+		style   Style
+		subject string
+		scope   string
+	}{
+		{
+			style:   Style{},
+			subject: "Support GitHub URLs in JSON output",
+			scope:   "iOS",
+		},
+		{
+			style:   Style{SubjectCase: LowerCase, ScopeCase: UpperCase},
+			subject: "support github urls in json output",
+			scope:   "IOS",
+		},
+		{
+			style:   Style{MaxSubjectLength: 14, Ellipsis: "…"},
+			subject: "Support GitHub…",
+			scope:   "iOS",
+		},
+		{
+			style:   Style{MaxSubjectLength: 100, Ellipsis: "…"},
+			subject: "Support GitHub URLs in JSON output",
+			scope:   "iOS",
+		},
+	}
+	for i, row := range table {
+		commit := &repository.Commit{
+			Subject: "Support GitHub URLs in JSON output",
+			Scope:   "iOS",
+		}
+		styled := row.style.Apply(commit)
+		if styled.Subject != row.subject {
+			t.Fatalf("[%d] expected subject %#v, got %#v", i, row.subject, styled.Subject)
+		}
+		if styled.Scope != row.scope {
+			t.Fatalf("[%d] expected scope %#v, got %#v", i, row.scope, styled.Scope)
+		}
+		if commit.Subject != "Support GitHub URLs in JSON output" {
+			t.Fatalf("[%d] commit was modified", i)
+		}
+	}
+}
+
+func TestCase(t *testing.T) {
+	table := []struct {
+		in  string
+		c   Case
+		out string
+	}{
+		{in: "über", c: SentenceCase, out: "Über"},
+		{in: "", c: SentenceCase, out: ""},
+		{in: "MiXeD", c: KeepCase, out: "MiXeD"},
+	}
+	for i, row := range table {
+		out := row.c.Apply(row.in)
+		if out != row.out {
+			t.Fatalf("[%d] expected %#v, got %#v", i, row.out, out)
+		}
+	}
+	_, err := ParseCase("camel")
+	if err != ErrUnknownCase {
+		t.Fatalf("expected ErrUnknownCase, got %v", err)
+	}
+}
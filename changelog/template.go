@@ -0,0 +1,67 @@
+package changelog
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+)
+
+// DefaultTemplate renders a release like the DefaultFormatFunc
+// and the DefaultBreakingFormatFunc. Additionally it links the
+// release, commits and issues and lists the closed issues
+const DefaultTemplate = `{{ if .Version }}## {{ link .Version .CompareURL }} ({{ .Date }})
+
+{{ else if .Unreleased }}## {{ link "Unreleased" .CompareURL }}
+
+{{ end }}
+{{- range .Sections }}#### {{ .Title }}
+
+{{ range .Entries }}* {{ linkIssues .Issues (indent 2 .Text) }}
+{{- if .Scope }} [{{ linkIssues .Issues .Scope }}]{{ end }}
+{{- if .Closes }}, closes {{ range $i, $issue := .Closes }}{{ if $i }}, {{ end }}{{ link .ID .URL }}{{ end }}{{ end }}
+{{- if .Hash }} ({{ link (shortSHA .Hash) .URL }}) {{ end }}
+{{ end }}
+{{ end }}`
+
+// TemplateFuncs are the helper functions available in templates
+var TemplateFuncs = template.FuncMap{
+	// shortSHA returns the leading 8 characters of a commit hash
+	"shortSHA": TrimSHA,
+	"upper":    strings.ToUpper,
+	"lower":    strings.ToLower,
+	// join concatenates the elements of a slice, e.g. {{ .Footers | join ", " }}
+	"join": join,
+	// link returns a Markdown link, or the text if the url is empty
+	"link": func(text, url string) string {
+		if url == "" {
+			return text
+		}
+		return "[" + text + "](" + url + ")"
+	},
+	// linkIssues replaces the references to the issues in the text with links
+	"linkIssues": linkIssues,
+	// indent indents all but the first line by n spaces
+	"indent": func(n int, s string) string {
+		return strings.Replace(s, "\n", "\n"+strings.Repeat(" ", n), -1)
+	},
+}
+
+// ParseTemplate parses a changelog template that
+// is executed with a Release and may use the TemplateFuncs
+func ParseTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(TemplateFuncs).Parse(text)
+}
+
+// join formats the elements of the slice and concatenates them
+func join(sep string, elems interface{}) (string, error) {
+	v := reflect.ValueOf(elems)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return "", fmt.Errorf("join: expected a slice, got %T", elems)
+	}
+	parts := make([]string, v.Len())
+	for i := range parts {
+		parts[i] = fmt.Sprint(v.Index(i).Interface())
+	}
+	return strings.Join(parts, sep), nil
+}
@@ -0,0 +1,79 @@
+package changelog
+
+import (
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/moolen/asdf/repository"
+)
+
+func TestDefaultTemplate(t *testing.T) {
+	commits := []*repository.Commit{
+		{Hash: "1234567890", Type: "feat", Scope: "api", Subject: "new api", Breaking: true, BreakingDescription: "the old api is gone\nuse the new one"},
+		{Hash: "abcdef1234", Type: "fix", Subject: "bug"},
+		{Hash: "5678", Type: "breaking", Subject: "drop v1", Breaking: true},
+		{Hash: "0000000000", Type: "", Subject: "untyped"},
+	}
+	tmpl, err := ParseTemplate("default", DefaultTemplate)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	newChangelog := func() *Changelog {
+		cl := New(map[string]string{"feat": "Feature", "breaking": "Breaking Changes"}, DefaultFormatFunc)
+		cl.Dependencies = []Dependency{{Name: "lib", Version: semver.MustParse("1.0.0")}}
+		return cl
+	}
+	releases := []*Release{
+		newChangelog().Release(commits, nil),
+		newChangelog().Release(commits, semver.MustParse("1.2.3")),
+		newChangelog().Unreleased(commits),
+	}
+	for i, release := range releases {
+		cl := newChangelog()
+		expected, err := cl.Markdown(release)
+		if err != nil {
+			t.Fatalf("[%d] unexpected error: %v", i, err)
+		}
+		cl.Template = tmpl
+		out, err := cl.Markdown(release)
+		if err != nil {
+			t.Fatalf("[%d] unexpected error: %v", i, err)
+		}
+		if out != expected {
+			t.Fatalf("[%d] default template did not match\nexpected\n%#v\ngot\n%#v", i, expected, out)
+		}
+	}
+}
+
+func TestTemplateFuncs(t *testing.T) {
+	text := `{{ upper .Version }}{{ range .Sections }}|{{ lower .Title }}:{{ range .Entries }}{{ link (shortSHA .Hash) .Hash }} {{ .Text }} {{ .Footers | join ", " }};{{ end }}{{ end }}`
+	tmpl, err := ParseTemplate("custom", text)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cl := New(map[string]string{"fix": "Bug Fixes"}, DefaultFormatFunc)
+	cl.Template = tmpl
+	out, err := cl.Create([]*repository.Commit{
+		{
+			Hash:    "1234567890",
+			Type:    "fix",
+			Subject: "bug",
+			Footers: []repository.Footer{{Token: "Closes", Value: "#12"}, {Token: "Reviewed-by", Value: "Jane"}},
+		},
+		{Type: "fix", Subject: "no hash"},
+	}, semver.MustParse("1.0.0-rc.1"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "1.0.0-RC.1|bug fixes:[12345678](1234567890) bug Closes #12, Reviewed-by: Jane; no hash ;"
+	if out != expected {
+		t.Fatalf("expected\n%s\ngot\n%s", expected, out)
+	}
+
+	tmpl, _ = ParseTemplate("broken", "{{ .Unknown }}")
+	cl.Template = tmpl
+	_, err = cl.Create(nil, nil)
+	if err == nil {
+		t.Fatal("expected error for unknown field")
+	}
+}
@@ -1,10 +1,16 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
+	"io/ioutil"
+	"os"
+	"path"
 	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/moolen/asdf/changelog"
 	"github.com/moolen/asdf/repository"
 	"github.com/urfave/cli"
 )
@@ -34,6 +40,10 @@ func TestChangelogCommand(t *testing.T) {
 			args: []string{"--revision", "HEAD", "--version", "2.3.4", "--dir"},
 			err:  nil,
 		},
+		{
+			args: []string{"--format", "pdf", "--revision", "HEAD", "--version", "2.3.4", "--dir"},
+			err:  cli.NewExitError(changelog.ErrUnknownFormat, 1),
+		},
 	}
 
 	for i, row := range table {
@@ -46,10 +56,128 @@ func TestChangelogCommand(t *testing.T) {
 		flagSet.Parse(append(row.args, repo))
 		ctx := cli.NewContext(&cli.App{}, flagSet, nil)
 		err := changelogCommand(ctx)
-		if !reflect.DeepEqual(err, row.err) {
+		if !sameExitError(err, row.err) {
 			t.Fatalf("[%d] expected\n%#v\ngot\n%#v", i, row.err, err)
 		}
 
 	}
 
 }
+
+// sameExitError is true if both errors have the same exit code and the
+// message of got starts with the expected one. Failed git commands
+// append the command and its error output to repository.ErrExec
+func sameExitError(got, expected error) bool {
+	if got == nil || expected == nil {
+		return got == expected
+	}
+	gotCoder, ok := got.(cli.ExitCoder)
+	expectedCoder, expectedOk := expected.(cli.ExitCoder)
+	return ok && expectedOk && gotCoder.ExitCode() == expectedCoder.ExitCode() &&
+		strings.HasPrefix(got.Error(), expected.Error())
+}
+
+func TestChangelogCommandJSON(t *testing.T) {
+	flagSet := flag.NewFlagSet("", flag.ContinueOnError)
+	for _, flag := range append(changelogFlags(), globalFlags()...) {
+		flag.Apply(flagSet)
+	}
+	repo := createRepository()
+	createAndCommit(repo, "feat(api): new endpoint", "Closes #12")
+	flagSet.Parse([]string{"--format", "json", "--dir", repo})
+	stdout := os.Stdout
+	tempfile, _ := ioutil.TempFile("", "")
+	defer tempfile.Close()
+	os.Stdout = tempfile
+	err := changelogCommand(cli.NewContext(&cli.App{}, flagSet, nil))
+	os.Stdout = stdout
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out, _ := ioutil.ReadFile(tempfile.Name())
+	var releases []*changelog.Release
+	err = json.Unmarshal(out, &releases)
+	if err != nil {
+		t.Fatalf("invalid json %s: %v", out, err)
+	}
+	if len(releases) != 1 || releases[0].Version != "1.1.0" || len(releases[0].Sections) != 1 {
+		t.Fatalf("unexpected releases %s", out)
+	}
+	entry := releases[0].Sections[0].Entries[0]
+	if entry.Subject != "new endpoint" || entry.Scope != "api" || len(entry.Hash) != 40 ||
+		!reflect.DeepEqual(entry.Footers, []repository.Footer{{Token: "Closes", Value: "#12"}}) {
+		t.Fatalf("unexpected entry %#v", entry)
+	}
+}
+
+func TestChangelogCommandTemplate(t *testing.T) {
+	repo := createRepository()
+	createAndCommit(repo, "feat(api): new endpoint", "")
+	ioutil.WriteFile(path.Join(repo, "release.tmpl"), []byte("{{ .Version }}{{ range .Sections }} {{ upper .Title }}{{ end }}"), os.ModePerm)
+	table := []struct {
+		args   []string
+		stdout string
+		err    bool
+	}{
+		{args: []string{"--template", "release.tmpl"}, stdout: "1.1.0 FEATURE"},
+		{args: []string{"--template", path.Join(repo, "release.tmpl")}, stdout: "1.1.0 FEATURE"},
+		{args: []string{"--template", "missing.tmpl"}, err: true},
+	}
+	for i, row := range table {
+		flagSet := flag.NewFlagSet("", flag.ContinueOnError)
+		for _, flag := range append(changelogFlags(), globalFlags()...) {
+			flag.Apply(flagSet)
+		}
+		flagSet.Parse(append(row.args, "--dir", repo))
+		stdout := os.Stdout
+		tempfile, _ := ioutil.TempFile("", "")
+		defer tempfile.Close()
+		os.Stdout = tempfile
+		err := changelogCommand(cli.NewContext(&cli.App{}, flagSet, nil))
+		os.Stdout = stdout
+		if row.err {
+			if err == nil {
+				t.Fatalf("[%d] expected error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("[%d] unexpected error: %v", i, err)
+		}
+		out, _ := ioutil.ReadFile(tempfile.Name())
+		if string(out) != row.stdout {
+			t.Fatalf("[%d] expected %q, got %q", i, row.stdout, out)
+		}
+	}
+}
+
+func TestChangelogCommandAll(t *testing.T) {
+	flagSet := flag.NewFlagSet("", flag.ContinueOnError)
+	for _, flag := range append(changelogFlags(), globalFlags()...) {
+		flag.Apply(flagSet)
+	}
+	repo := createHistory()
+	flagSet.Parse([]string{"--all", "--format", "json", "--dir", repo})
+	stdout := os.Stdout
+	tempfile, _ := ioutil.TempFile("", "")
+	defer tempfile.Close()
+	os.Stdout = tempfile
+	err := changelogCommand(cli.NewContext(&cli.App{}, flagSet, nil))
+	os.Stdout = stdout
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out, _ := ioutil.ReadFile(tempfile.Name())
+	var releases []*changelog.Release
+	err = json.Unmarshal(out, &releases)
+	if err != nil {
+		t.Fatalf("invalid json %s: %v", out, err)
+	}
+	var versions []string
+	for _, release := range releases {
+		versions = append(versions, release.Version+" "+release.Date)
+	}
+	if len(versions) != 3 || versions[0] != "1.1.1 2020-02-03" || versions[1] != "1.1.0 2020-01-02" {
+		t.Fatalf("unexpected releases %v", versions)
+	}
+}
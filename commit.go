@@ -0,0 +1,281 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/moolen/asdf/repository"
+	"github.com/urfave/cli"
+)
+
+// maxScopeSuggestions limits the scopes suggested from the history
+const maxScopeSuggestions = 5
+
+// scopeHistoryDepth is the number of recent commits scopes are suggested from
+const scopeHistoryDepth = 100
+
+var errNoType = errors.New("a type is required")
+var errNoSubject = errors.New("a subject is required")
+
+// commitMessage holds the parts of a conventional commit message
+type commitMessage struct {
+	Type     string
+	Scope    string
+	Subject  string
+	Body     string
+	Breaking string
+	Closes   []string
+}
+
+// String composes the message, the breaking change and
+// the closed issues are added as footers
+func (m commitMessage) String() string {
+	header := m.Type
+	if m.Scope != "" {
+		header += "(" + m.Scope + ")"
+	}
+	if m.Breaking != "" {
+		header += "!"
+	}
+	message := header + ": " + m.Subject
+	if m.Body != "" {
+		message += "\n\n" + m.Body
+	}
+	var footers []string
+	if m.Breaking != "" {
+		footers = append(footers, "BREAKING CHANGE: "+m.Breaking)
+	}
+	for _, issue := range m.Closes {
+		if _, err := strconv.Atoi(strings.TrimPrefix(issue, "#")); err != nil {
+			// a ticket like PROJ-7
+			footers = append(footers, "Closes: "+issue)
+			continue
+		}
+		footers = append(footers, "Closes #"+strings.TrimPrefix(issue, "#"))
+	}
+	if len(footers) > 0 {
+		message += "\n\n" + strings.Join(footers, "\n")
+	}
+	return message + "\n"
+}
+
+// prompter asks for the parts of a commit message that are missing
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// ask writes the question and returns the trimmed answer
+func (p *prompter) ask(question string) (string, error) {
+	fmt.Fprint(p.out, question)
+	line, err := p.in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// askType asks for one of the types by number or name
+func (p *prompter) askType(types []string, labels map[string]string) (string, error) {
+	for i, t := range types {
+		fmt.Fprintf(p.out, "%2d) %-10s %s\n", i+1, t, labels[t])
+	}
+	for {
+		answer, err := p.ask("type: ")
+		if err != nil {
+			return "", err
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n > 0 && n <= len(types) {
+			return types[n-1], nil
+		}
+		if contains(types, answer) {
+			return answer, nil
+		}
+		fmt.Fprintf(p.out, "unknown type %q\n", answer)
+	}
+}
+
+// askBody reads paragraphs until an empty line
+func (p *prompter) askBody(question string) (string, error) {
+	fmt.Fprintln(p.out, question)
+	var lines []string
+	for {
+		line, err := p.ask("")
+		if err != nil || line == "" {
+			return strings.Join(lines, "\n"), err
+		}
+		lines = append(lines, line)
+	}
+}
+
+// complete asks for the parts of the message that are not set yet
+func (p *prompter) complete(msg *commitMessage, types []string, labels map[string]string, scopes []string) error {
+	var err error
+	if msg.Type == "" {
+		msg.Type, err = p.askType(types, labels)
+		if err != nil {
+			return err
+		}
+	}
+	if msg.Scope == "" {
+		question := "scope (optional): "
+		if len(scopes) > 0 {
+			question = "scope (optional, recently used: " + strings.Join(scopes, ", ") + "): "
+		}
+		msg.Scope, err = p.ask(question)
+		if err != nil {
+			return err
+		}
+	}
+	for msg.Subject == "" {
+		msg.Subject, err = p.ask("subject: ")
+		if err != nil {
+			return err
+		}
+	}
+	if msg.Body == "" {
+		msg.Body, err = p.askBody("body (optional, finish with an empty line):")
+		if err != nil {
+			return err
+		}
+	}
+	if msg.Breaking == "" {
+		msg.Breaking, err = p.ask("breaking change description (empty if none): ")
+		if err != nil {
+			return err
+		}
+	}
+	if len(msg.Closes) == 0 {
+		answer, err := p.ask("closed issues, e.g. \"#12 PROJ-7\" (optional): ")
+		if err != nil {
+			return err
+		}
+		msg.Closes = strings.FieldsFunc(answer, func(r rune) bool {
+			return r == ' ' || r == ','
+		})
+	}
+	return nil
+}
+
+// recentScopes returns the scopes of the commits ordered by their frequency
+func recentScopes(commits repository.Commits, max int) []string {
+	count := make(map[string]int)
+	var scopes []string
+	for _, commit := range commits {
+		if commit.Scope == "" {
+			continue
+		}
+		if count[commit.Scope] == 0 {
+			scopes = append(scopes, commit.Scope)
+		}
+		count[commit.Scope]++
+	}
+	sort.SliceStable(scopes, func(i, j int) bool {
+		return count[scopes[i]] > count[scopes[j]]
+	})
+	if len(scopes) > max {
+		scopes = scopes[:max]
+	}
+	return scopes
+}
+
+// commit composes a conventional commit message and commits the staged changes.
+// Parts that are not given as flags are asked for on stdin,
+// unless --non-interactive is set. The message is linted before it is committed
+func commitCommand(c *cli.Context) error {
+	cwd, err := getCwd(c)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	cfg, err := loadConfig(c, cwd)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	repo := cfg.newRepository(cwd)
+	msg := commitMessage{
+		Type:     c.String(flagType),
+		Scope:    c.String(flagScope),
+		Subject:  c.String(flagSubject),
+		Body:     c.String(flagBody),
+		Breaking: c.String(flagBreaking),
+		Closes:   c.StringSlice(flagCloses),
+	}
+	if !c.Bool(flagNonInteractive) {
+		var scopes []string
+		commits, err := repo.GetHistory("HEAD")
+		if err == nil {
+			if len(commits) > scopeHistoryDepth {
+				commits = commits[:scopeHistoryDepth]
+			}
+			scopes = recentScopes(commits, maxScopeSuggestions)
+		}
+		p := &prompter{in: bufio.NewReader(os.Stdin), out: os.Stderr}
+		err = p.complete(&msg, cfg.lintTypes(), cfg.Types, scopes)
+		if err != nil {
+			return cli.NewExitError(err, 2)
+		}
+	}
+	if msg.Type == "" {
+		return cli.NewExitError(errNoType, 2)
+	}
+	if msg.Subject == "" {
+		return cli.NewExitError(errNoSubject, 2)
+	}
+	message := msg.String()
+	if problems := cfg.lintMessage(message); len(problems) > 0 {
+		writeLintProblems(os.Stderr, "", strings.SplitN(message, "\n", 2)[0], problems)
+		return cli.NewExitError(errLintFailed, 3)
+	}
+	if c.Bool(flagDryRun) {
+		os.Stdout.WriteString(message)
+		return nil
+	}
+	_, err = repo.Commit(message)
+	if err != nil {
+		return cli.NewExitError(err, 4)
+	}
+	return nil
+}
+
+func commitFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  flagType,
+			Usage: "type of the commit, e.g. \"feat\"",
+		},
+		cli.StringFlag{
+			Name:  flagScope,
+			Usage: "scope of the commit",
+		},
+		cli.StringFlag{
+			Name:  flagSubject,
+			Usage: "subject of the commit",
+		},
+		cli.StringFlag{
+			Name:  flagBody,
+			Usage: "body of the commit",
+		},
+		cli.StringFlag{
+			Name:  flagBreaking,
+			Usage: "description of the breaking change",
+		},
+		cli.StringSliceFlag{
+			Name:  flagCloses,
+			Usage: "issue closed by the commit, e.g. \"#12\" or \"PROJ-7\". May be repeated",
+		},
+		cli.BoolFlag{
+			Name:  flagNonInteractive,
+			Usage: "do not ask for missing parts, --" + flagType + " and --" + flagSubject + " are required",
+		},
+		cli.BoolFlag{
+			Name:  flagDryRun,
+			Usage: "print the message instead of committing",
+		},
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/moolen/asdf/repository"
+	"github.com/urfave/cli"
+)
+
+func TestCommitMessage(t *testing.T) {
+	table := []struct {
+		msg      commitMessage
+		expected string
+	}{
+		{
+			msg:      commitMessage{Type: "fix", Subject: "a bug"},
+			expected: "fix: a bug\n",
+		},
+		{
+			msg: commitMessage{
+				Type:     "feat",
+				Scope:    "api",
+				Subject:  "new endpoint",
+				Body:     "the endpoint replaces v1",
+				Breaking: "v1 is gone",
+				Closes:   []string{"#12", "13", "PROJ-7"},
+			},
+			expected: "feat(api)!: new endpoint\n\nthe endpoint replaces v1\n\nBREAKING CHANGE: v1 is gone\nCloses #12\nCloses #13\nCloses: PROJ-7\n",
+		},
+	}
+	for i, row := range table {
+		if message := row.msg.String(); message != row.expected {
+			t.Fatalf("[%d] expected\n%q\ngot\n%q", i, row.expected, message)
+		}
+		parsed := repository.DefaultMapFunc(strings.SplitN(row.expected, "\n", 2)[0], row.expected)
+		if parsed.Type != row.msg.Type || parsed.Scope != row.msg.Scope || parsed.Subject != row.msg.Subject {
+			t.Fatalf("[%d] message is not parsed back %#v", i, parsed)
+		}
+	}
+}
+
+func TestPrompterComplete(t *testing.T) {
+	input := "unknown\n2\napi\n\nnew endpoint\nfirst line\nsecond line\n\nv1 is gone\n#12, PROJ-7\n"
+	var out strings.Builder
+	p := &prompter{in: bufio.NewReader(strings.NewReader(input)), out: &out}
+	var msg commitMessage
+	err := p.complete(&msg, []string{"fix", "feat"}, DefaultTypeMap, []string{"api", "cli"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := commitMessage{
+		Type:     "feat",
+		Scope:    "api",
+		Subject:  "new endpoint",
+		Body:     "first line\nsecond line",
+		Breaking: "v1 is gone",
+		Closes:   []string{"#12", "PROJ-7"},
+	}
+	if !reflect.DeepEqual(msg, expected) {
+		t.Fatalf("expected %#v, got %#v", expected, msg)
+	}
+	for _, s := range []string{" 2) feat       Feature\n", "unknown type \"unknown\"", "recently used: api, cli"} {
+		if !strings.Contains(out.String(), s) {
+			t.Fatalf("expected prompts to contain %q, got\n%s", s, out.String())
+		}
+	}
+
+	// parts given as flags are not asked for
+	p = &prompter{in: bufio.NewReader(strings.NewReader("")), out: &out}
+	msg = commitMessage{Type: "fix", Scope: "api", Subject: "bug", Body: "body", Breaking: "gone", Closes: []string{"#1"}}
+	if err := p.complete(&msg, nil, nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestRecentScopes(t *testing.T) {
+	commits := repository.Commits{
+		{Scope: "cli"}, {Scope: "api"}, {}, {Scope: "api"}, {Scope: "db"},
+	}
+	if scopes := recentScopes(commits, 2); !reflect.DeepEqual(scopes, []string{"api", "cli"}) {
+		t.Fatalf("unexpected scopes %v", scopes)
+	}
+}
+
+func TestCommitCommand(t *testing.T) {
+	table := []struct {
+		args    []string
+		subject string
+		err     error
+	}{
+		{
+			args:    []string{"--type", "feat", "--scope", "api", "--subject", "new endpoint", "--closes", "#12"},
+			subject: "new endpoint",
+		},
+		{
+			args: []string{"--subject", "new endpoint"},
+			err:  cli.NewExitError(errNoType, 2),
+		},
+		{
+			args: []string{"--type", "feature", "--subject", "new endpoint"},
+			err:  cli.NewExitError(errLintFailed, 3),
+		},
+	}
+	for i, row := range table {
+		repo := createRepository()
+		ioutil.WriteFile(path.Join(repo, "staged"), []byte("x"), os.ModePerm)
+		execDir(repo, "git", "add", "staged")
+		flagSet := flag.NewFlagSet("", flag.ContinueOnError)
+		for _, flag := range append(commitFlags(), globalFlags()...) {
+			flag.Apply(flagSet)
+		}
+		flagSet.Parse(append(row.args, "--non-interactive", "--dir", repo))
+		stderr := os.Stderr
+		os.Stderr, _ = os.Open(os.DevNull)
+		err := commitCommand(cli.NewContext(&cli.App{}, flagSet, nil))
+		os.Stderr = stderr
+		if !reflect.DeepEqual(err, row.err) {
+			t.Fatalf("[%d] expected\n%#v\ngot\n%#v", i, row.err, err)
+		}
+		commits, _ := repository.New(repo, repository.DefaultMapFunc).GetHistory("HEAD")
+		if row.subject == "" {
+			if commits[0].Subject != "initial commit" {
+				t.Fatalf("[%d] unexpected commit %#v", i, commits[0])
+			}
+			continue
+		}
+		if commits[0].Subject != row.subject || commits[0].Type != "feat" || commits[0].Scope != "api" ||
+			!reflect.DeepEqual(commits[0].Footers, []repository.Footer{{Token: "Closes", Value: "#12"}}) {
+			t.Fatalf("[%d] unexpected commit %#v", i, commits[0])
+		}
+	}
+}
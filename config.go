@@ -0,0 +1,482 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"text/template"
+
+	"github.com/moolen/asdf/changelog"
+	"github.com/moolen/asdf/repository"
+	"github.com/urfave/cli"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// defaultConfigFile is looked up in the working directory
+// if no config file is specified via --config
+const defaultConfigFile = ".asdf.yml"
+
+// defaultTemplate is the name of the built-in changelog template
+const defaultTemplate = "default"
+
+// templates contains the built-in changelog templates by name
+var templates = map[string]string{
+	defaultTemplate: changelog.DefaultTemplate,
+}
+
+// Config holds the project configuration.
+// It is read from a .asdf.yml file in the repository root,
+// command line flags take precedence over the values in that file
+type Config struct {
+	// VersionFile contains the latest released version
+	VersionFile string `yaml:"version_file"`
+	// ChangelogFile is the file the changelog is written to
+	ChangelogFile string `yaml:"changelog_file"`
+	// Source defines where the latest release is looked up:
+	// the version file or the release tags
+	Source string `yaml:"source"`
+	// TagPrefix is the prefix of release tags, e.g. `v`.
+	// It defaults to `v` for the tag source
+	TagPrefix string `yaml:"tag_prefix"`
+	// History selects the commits: no-merges, merges-only or first-parent
+	History string `yaml:"history"`
+	// GitBackend selects how git is accessed: auto, exec or native.
+	// auto executes the git binary if it is installed
+	GitBackend string `yaml:"git_backend"`
+	// Prerelease is the pattern of prerelease versions, e.g. `rc.{RELEASE_NUMBER}`
+	Prerelease string `yaml:"prerelease"`
+	// Build is the pattern of the build metadata, e.g. `sha.{COMMIT_SHA}`
+	Build string `yaml:"build"`
+	// InitialDevelopment lowers the change while the major version is 0:
+	// breaking changes bump the minor and features the patch version
+	InitialDevelopment bool `yaml:"initial_development"`
+	// ReleaseAs is the explicit version of the next release
+	ReleaseAs string `yaml:"-"`
+	// Template is the name of a built-in template or the path
+	// of a text/template file used to render the changelog
+	Template string `yaml:"template"`
+	// Marker is the line of the changelog file after which new releases
+	// are inserted. Everything above is preserved, defaults to changelog.DefaultMarker
+	Marker string `yaml:"marker"`
+	// Types maps a commit type to the label of its changelog section.
+	// These are merged with the DefaultTypeMap
+	Types map[string]string `yaml:"types"`
+	// Order defines the order of the changelog sections by commit type.
+	// Defaults to changelog.DefaultOrder
+	Order []string `yaml:"order"`
+	// Hide contains the commit types that are not listed in the changelog
+	Hide []string `yaml:"hide"`
+	// CollapseUnknown lists the commits of types that are not part
+	// of the Types in the section of the `other` type
+	CollapseUnknown bool `yaml:"collapse_unknown"`
+	// Bump contains the rules which determine the change a commit causes.
+	// They take precedence over the repository.DefaultRules
+	Bump []BumpRule `yaml:"bump"`
+	// Style defines how commit subjects and scopes are presented in the changelog
+	Style StyleConfig `yaml:"style"`
+	// Release defines whether generate commits and tags the release
+	Release ReleaseConfig `yaml:"release"`
+	// Links defines the URLs of commits, releases and issues
+	Links LinksConfig `yaml:"links"`
+	// Lint defines the rules of the lint command
+	Lint LintConfig `yaml:"lint"`
+	// Packages are released independently, see PackageConfig
+	Packages []PackageConfig `yaml:"packages"`
+	// Package selects a single package by name or path
+	Package string `yaml:"-"`
+
+	rules repository.Rules
+	style changelog.Style
+	// pkg is the package this config was derived for, see forPackage
+	pkg *PackageConfig
+	// updated are the dependencies of the package released in this run
+	updated []changelog.Dependency
+	// template is the parsed Template
+	template *template.Template
+	// links are the resolved Links
+	links changelog.Links
+	// lintCase is the parsed Lint.SubjectCase
+	lintCase changelog.Case
+	// history is the parsed History
+	history repository.HistoryMode
+	// gitBackend is the parsed GitBackend
+	gitBackend repository.BackendKind
+	// tagPrefixSet is true if the TagPrefix is configured, even if it is empty
+	tagPrefixSet bool
+}
+
+// PackageConfig is a package of a monorepo with its own
+// version, changelog and release tags. Only commits that
+// touch the path of the package are considered
+type PackageConfig struct {
+	// Name defaults to the path
+	Name string `yaml:"name"`
+	// Path is relative to the repository root
+	Path string `yaml:"path"`
+	// TagPrefix defaults to `<name>/v`
+	TagPrefix string `yaml:"tag_prefix"`
+	// VersionFile and ChangelogFile are relative to the path
+	// and default to the files of the config
+	VersionFile   string `yaml:"version_file"`
+	ChangelogFile string `yaml:"changelog_file"`
+	// DependsOn contains the names of the packages this package uses.
+	// Sibling Go modules are added from the go.mod file
+	DependsOn []string `yaml:"depends_on"`
+}
+
+// ReleaseConfig defines whether generate creates
+// a release commit and an annotated tag
+type ReleaseConfig struct {
+	Commit        bool   `yaml:"commit"`
+	CommitMessage string `yaml:"commit_message"`
+	Tag           bool   `yaml:"tag"`
+	Sign          bool   `yaml:"sign"`
+}
+
+// StyleConfig is the config representation of a changelog.Style
+type StyleConfig struct {
+	SubjectCase      string `yaml:"subject_case"`
+	ScopeCase        string `yaml:"scope_case"`
+	MaxSubjectLength int    `yaml:"max_subject_length"`
+	Ellipsis         string `yaml:"ellipsis"`
+}
+
+// BumpRule maps a commit type and an optional scope
+// to a change: major, minor, patch or none
+type BumpRule struct {
+	Type   string `yaml:"type"`
+	Scope  string `yaml:"scope"`
+	Change string `yaml:"change"`
+}
+
+// defaultConfig returns the configuration used if there is no config file
+func defaultConfig() *Config {
+	types := make(map[string]string)
+	for t, label := range DefaultTypeMap {
+		types[t] = label
+	}
+	return &Config{
+		VersionFile:   "VERSION",
+		ChangelogFile: "CHANGELOG.md",
+		Source:        sourceFile,
+		Template:      defaultTemplate,
+		Marker:        changelog.DefaultMarker,
+		Order:         changelog.DefaultOrder,
+		Release: ReleaseConfig{
+			CommitMessage: defaultCommitMessage,
+		},
+		Lint: LintConfig{
+			MaxHeaderLength: defaultMaxHeaderLength,
+		},
+		Types: types,
+		rules: repository.DefaultRules,
+	}
+}
+
+// loadConfig reads the config file from the working directory
+// or the location specified via --config and applies the command line flags.
+// A missing config file is not an error unless it was explicitly specified
+func loadConfig(c *cli.Context, cwd string) (*Config, error) {
+	cfg := defaultConfig()
+	file := c.GlobalString(flagConfig)
+	explicit := file != ""
+	if !explicit {
+		file = defaultConfigFile
+	}
+	if !path.IsAbs(file) {
+		file = path.Join(cwd, file)
+	}
+	content, err := ioutil.ReadFile(file)
+	if err != nil && (explicit || !os.IsNotExist(err)) {
+		return nil, err
+	}
+	if err == nil {
+		err = cfg.parse(content)
+		if err != nil {
+			return nil, fmt.Errorf("invalid config file %s: %s", file, err)
+		}
+	}
+	err = cfg.resolveDependencies(cwd)
+	if err != nil {
+		return nil, err
+	}
+	if c.IsSet(flagFile) {
+		cfg.VersionFile = c.String(flagFile)
+	}
+	if c.IsSet(flagChangelog) {
+		cfg.ChangelogFile = c.String(flagChangelog)
+	}
+	if c.IsSet(flagSource) {
+		cfg.Source = c.String(flagSource)
+	}
+	if c.IsSet(flagTagPrefix) {
+		cfg.TagPrefix = c.String(flagTagPrefix)
+		cfg.tagPrefixSet = true
+	}
+	if c.IsSet(flagHistory) {
+		cfg.History = c.String(flagHistory)
+	}
+	cfg.history, err = repository.ParseHistoryMode(cfg.History)
+	if err != nil {
+		return nil, err
+	}
+	if c.GlobalIsSet(flagGitBackend) {
+		cfg.GitBackend = c.GlobalString(flagGitBackend)
+	}
+	cfg.gitBackend, err = repository.ParseBackendKind(cfg.GitBackend)
+	if err != nil {
+		return nil, err
+	}
+	if c.IsSet(flagPrerelease) {
+		cfg.Prerelease = c.String(flagPrerelease)
+	}
+	if c.IsSet(flagBuild) {
+		cfg.Build = c.String(flagBuild)
+	}
+	if c.IsSet(flagInitialDev) {
+		cfg.InitialDevelopment = c.Bool(flagInitialDev)
+	}
+	cfg.ReleaseAs = c.String(flagReleaseAs)
+	cfg.Package = c.String(flagPackage)
+	if c.IsSet(flagTemplate) {
+		cfg.Template = c.String(flagTemplate)
+	}
+	cfg.template, err = loadTemplate(cwd, cfg.Template)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Links.Provider != "" {
+		if _, ok := providerLinks[cfg.Links.Provider]; !ok {
+			return nil, fmt.Errorf("unknown links provider %s: expected github, gitlab, bitbucket or gitea", cfg.Links.Provider)
+		}
+	}
+	cfg.links = resolveLinks(cfg.newRepository(cwd), cfg.Links)
+	if c.IsSet(flagCommit) {
+		cfg.Release.Commit = c.Bool(flagCommit)
+	}
+	if c.IsSet(flagCommitMsg) {
+		cfg.Release.CommitMessage = c.String(flagCommitMsg)
+	}
+	if c.IsSet(flagTag) {
+		cfg.Release.Tag = c.Bool(flagTag)
+	}
+	if c.IsSet(flagSign) {
+		cfg.Release.Sign = c.Bool(flagSign)
+	}
+	if cfg.Source != sourceFile && cfg.Source != sourceTag {
+		return nil, fmt.Errorf("unknown source %s: expected %s or %s", cfg.Source, sourceFile, sourceTag)
+	}
+	if cfg.Source == sourceTag && !cfg.tagPrefixSet {
+		cfg.TagPrefix = defaultTagPrefix
+	}
+	return cfg, nil
+}
+
+// parse merges the yaml encoded config into cfg
+func (cfg *Config) parse(content []byte) error {
+	var fileCfg Config
+	err := yaml.UnmarshalStrict(content, &fileCfg)
+	if err != nil {
+		return err
+	}
+	if fileCfg.VersionFile != "" {
+		cfg.VersionFile = fileCfg.VersionFile
+	}
+	if fileCfg.ChangelogFile != "" {
+		cfg.ChangelogFile = fileCfg.ChangelogFile
+	}
+	if fileCfg.Source != "" {
+		cfg.Source = fileCfg.Source
+	}
+	cfg.TagPrefix = fileCfg.TagPrefix
+	// an empty tag_prefix differs from a missing one
+	var prefix struct {
+		TagPrefix *string `yaml:"tag_prefix"`
+	}
+	err = yaml.Unmarshal(content, &prefix)
+	if err != nil {
+		return err
+	}
+	cfg.tagPrefixSet = prefix.TagPrefix != nil
+	cfg.History = fileCfg.History
+	cfg.GitBackend = fileCfg.GitBackend
+	cfg.Prerelease = fileCfg.Prerelease
+	cfg.Build = fileCfg.Build
+	cfg.InitialDevelopment = fileCfg.InitialDevelopment
+	if fileCfg.Release.CommitMessage == "" {
+		fileCfg.Release.CommitMessage = cfg.Release.CommitMessage
+	}
+	cfg.Release = fileCfg.Release
+	if fileCfg.Template != "" {
+		cfg.Template = fileCfg.Template
+	}
+	if fileCfg.Marker != "" {
+		cfg.Marker = fileCfg.Marker
+	}
+	for t, label := range fileCfg.Types {
+		cfg.Types[t] = label
+	}
+	if len(fileCfg.Order) > 0 {
+		cfg.Order = fileCfg.Order
+	}
+	cfg.Hide = fileCfg.Hide
+	cfg.Links = fileCfg.Links
+	cfg.CollapseUnknown = fileCfg.CollapseUnknown
+	names := make(map[string]bool)
+	for _, pkg := range fileCfg.Packages {
+		if pkg.Path == "" {
+			return fmt.Errorf("package %s without path", pkg.Name)
+		}
+		pkg = newPackage(pkg)
+		if names[pkg.Name] {
+			return fmt.Errorf("duplicate package %s", pkg.Name)
+		}
+		names[pkg.Name] = true
+		cfg.Packages = append(cfg.Packages, pkg)
+	}
+	cfg.Bump = fileCfg.Bump
+	var rules repository.Rules
+	for _, bump := range fileCfg.Bump {
+		if bump.Type == "" {
+			return fmt.Errorf("bump rule without type")
+		}
+		change, err := repository.ParseChange(bump.Change)
+		if err != nil {
+			return err
+		}
+		rules = append(rules, repository.Rule{
+			Type:   bump.Type,
+			Scope:  bump.Scope,
+			Change: change,
+		})
+	}
+	cfg.rules = append(rules, repository.DefaultRules...)
+	cfg.Style = fileCfg.Style
+	cfg.style.SubjectCase, err = changelog.ParseCase(fileCfg.Style.SubjectCase)
+	if err != nil {
+		return err
+	}
+	cfg.style.ScopeCase, err = changelog.ParseCase(fileCfg.Style.ScopeCase)
+	if err != nil {
+		return err
+	}
+	cfg.style.MaxSubjectLength = fileCfg.Style.MaxSubjectLength
+	cfg.style.Ellipsis = fileCfg.Style.Ellipsis
+	if fileCfg.Lint.MaxHeaderLength == 0 {
+		fileCfg.Lint.MaxHeaderLength = cfg.Lint.MaxHeaderLength
+	}
+	cfg.Lint = fileCfg.Lint
+	cfg.lintCase, err = changelog.ParseCase(fileCfg.Lint.SubjectCase)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// loadTemplate parses the built-in template with the given name
+// or the template file. A relative path is relative to the working directory
+func loadTemplate(cwd, name string) (*template.Template, error) {
+	if text, ok := templates[name]; ok {
+		return changelog.ParseTemplate(name, text)
+	}
+	file := name
+	if !path.IsAbs(file) {
+		file = path.Join(cwd, file)
+	}
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("unknown template %s: %s", name, err)
+	}
+	return changelog.ParseTemplate(path.Base(file), string(content))
+}
+
+// newPackage applies the defaults to a package
+func newPackage(pkg PackageConfig) PackageConfig {
+	pkg.Path = path.Clean(pkg.Path)
+	if pkg.Name == "" {
+		pkg.Name = pkg.Path
+	}
+	if pkg.TagPrefix == "" {
+		pkg.TagPrefix = pkg.Name + "/v"
+	}
+	return pkg
+}
+
+// packageConfigs returns a config for every package that is released.
+// That is the selected package, all configured packages or
+// the config itself if the repository does not contain packages.
+// A selected package that is not configured is looked up by path
+func (cfg *Config) packageConfigs() []*Config {
+	if cfg.Package != "" {
+		for _, pkg := range cfg.Packages {
+			if pkg.Name == cfg.Package || pkg.Path == path.Clean(cfg.Package) {
+				return []*Config{cfg.forPackage(pkg)}
+			}
+		}
+		return []*Config{cfg.forPackage(newPackage(PackageConfig{Path: cfg.Package}))}
+	}
+	if len(cfg.Packages) == 0 {
+		return []*Config{cfg}
+	}
+	var configs []*Config
+	for _, pkg := range cfg.Packages {
+		configs = append(configs, cfg.forPackage(pkg))
+	}
+	return configs
+}
+
+// forPackage returns a copy of the config whose files
+// and tags are the ones of the package
+func (cfg *Config) forPackage(pkg PackageConfig) *Config {
+	pkgCfg := *cfg
+	pkgCfg.pkg = &pkg
+	pkgCfg.TagPrefix = pkg.TagPrefix
+	pkgCfg.VersionFile = path.Join(pkg.Path, cfg.VersionFile)
+	if pkg.VersionFile != "" {
+		pkgCfg.VersionFile = path.Join(pkg.Path, pkg.VersionFile)
+	}
+	pkgCfg.ChangelogFile = path.Join(pkg.Path, cfg.ChangelogFile)
+	if pkg.ChangelogFile != "" {
+		pkgCfg.ChangelogFile = path.Join(pkg.Path, pkg.ChangelogFile)
+	}
+	return &pkgCfg
+}
+
+// packageName returns the name of the package or
+// an empty string if the config is not for a package
+func (cfg *Config) packageName() string {
+	if cfg.pkg == nil {
+		return ""
+	}
+	return cfg.pkg.Name
+}
+
+// newRepository creates a repository that applies the configured bump rules
+// and history mode. For a package the history is restricted to its path
+func (cfg *Config) newRepository(cwd string) *repository.GitRepository {
+	repo := repository.New(cwd, repository.DefaultMapFunc)
+	repo.Backend = repository.NewBackend(cfg.gitBackend, cwd)
+	repo.ChangeFunc = cfg.rules.Change
+	repo.Mode = cfg.history
+	if cfg.pkg != nil {
+		repo.Paths = []string{cfg.pkg.Path}
+	}
+	return repo
+}
+
+// newChangelog creates a changelog generator from the config.
+// The commands always render with a template, the built-in default
+// template if none is configured, so the FormatFuncs are never used
+func (cfg *Config) newChangelog() *changelog.Changelog {
+	cl := changelog.New(cfg.Types, changelog.DefaultFormatFunc)
+	cl.Template = cfg.template
+	cl.Order = cfg.Order
+	cl.Hidden = cfg.Hide
+	cl.Links = cfg.links
+	cl.TagPrefix = cfg.TagPrefix
+	cl.CollapseUnknown = cfg.CollapseUnknown
+	cl.Style = cfg.style
+	cl.Dependencies = cfg.updated
+	return cl
+}
@@ -0,0 +1,385 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+
+	"github.com/moolen/asdf/changelog"
+	"github.com/moolen/asdf/repository"
+	"github.com/urfave/cli"
+)
+
+func TestLoadConfig(t *testing.T) {
+	table := []struct {
+		config string
+		args   []string
+		check  func(*Config) bool
+		err    bool
+	}{
+		{
+			// no config file: defaults
+			check: func(cfg *Config) bool {
+				return cfg.VersionFile == "VERSION" &&
+					cfg.ChangelogFile == "CHANGELOG.md" &&
+					reflect.DeepEqual(cfg.Types, DefaultTypeMap)
+			},
+		},
+		{
+			config: "version_file: VERSION.txt\nchangelog_file: docs/CHANGES.md\ntypes:\n  feat: New Stuff\n  ops: Operations\norder: [feat, fix]\nbump:\n  - type: perf\n    change: minor\n",
+			check: func(cfg *Config) bool {
+				return cfg.VersionFile == "VERSION.txt" &&
+					cfg.ChangelogFile == "docs/CHANGES.md" &&
+					cfg.Types["feat"] == "New Stuff" &&
+					cfg.Types["ops"] == "Operations" &&
+					cfg.Types["fix"] == "Bug Fixes" &&
+					reflect.DeepEqual(cfg.Order, []string{"feat", "fix"}) &&
+					cfg.rules.Change(&repository.Commit{Type: "perf"}) == repository.MinorChange &&
+					cfg.rules.Change(&repository.Commit{Type: "feat"}) == repository.MinorChange
+			},
+		},
+		{
+			// flags take precedence
+			config: "version_file: VERSION.txt\nchangelog_file: docs/CHANGES.md\n",
+			args:   []string{"--file", "OTHER", "--changelog", "CL.md"},
+			check: func(cfg *Config) bool {
+				return cfg.VersionFile == "OTHER" && cfg.ChangelogFile == "CL.md"
+			},
+		},
+		{
+			// the tag source defaults to tags like v1.4.2
+			args: []string{"--source", "tag"},
+			check: func(cfg *Config) bool {
+				return cfg.TagPrefix == "v"
+			},
+		},
+		{
+			config: "source: tag\ntag_prefix: \"\"\n",
+			check: func(cfg *Config) bool {
+				return cfg.TagPrefix == ""
+			},
+		},
+		{
+			args: []string{"--source", "tag", "--tag-prefix", ""},
+			check: func(cfg *Config) bool {
+				return cfg.TagPrefix == ""
+			},
+		},
+		{
+			// no default for the file source
+			check: func(cfg *Config) bool {
+				return cfg.TagPrefix == ""
+			},
+		},
+		{
+			config: "bump:\n  - type: perf\n    change: huge\n",
+			err:    true,
+		},
+		{
+			config: "bump:\n  - change: minor\n",
+			err:    true,
+		},
+		{
+			config: "history: merges-only\n",
+			check: func(cfg *Config) bool {
+				return cfg.history == repository.MergesOnly
+			},
+		},
+		{
+			config: "history: merges-only\n",
+			args:   []string{"--history", "first-parent"},
+			check: func(cfg *Config) bool {
+				return cfg.history == repository.FirstParent
+			},
+		},
+		{
+			config: "history: everything\n",
+			err:    true,
+		},
+		{
+			check: func(cfg *Config) bool {
+				return cfg.gitBackend == repository.AutoBackend
+			},
+		},
+		{
+			config: "git_backend: native\n",
+			args:   []string{"--git-backend", "exec"},
+			check: func(cfg *Config) bool {
+				return cfg.gitBackend == repository.ExecBackendKind
+			},
+		},
+		{
+			config: "git_backend: libgit2\n",
+			err:    true,
+		},
+		{
+			config: "style:\n  subject_case: lower\n  scope_case: upper\n  max_subject_length: 50\n  ellipsis: ...\n",
+			check: func(cfg *Config) bool {
+				return reflect.DeepEqual(cfg.newChangelog().Style, changelog.Style{
+					SubjectCase:      changelog.LowerCase,
+					ScopeCase:        changelog.UpperCase,
+					MaxSubjectLength: 50,
+					Ellipsis:         "...",
+				})
+			},
+		},
+		{
+			config: "style:\n  subject_case: camel\n",
+			err:    true,
+		},
+		{
+			config: "hide: [chore, test]\ncollapse_unknown: true\n",
+			check: func(cfg *Config) bool {
+				cl := cfg.newChangelog()
+				return reflect.DeepEqual(cl.Hidden, []string{"chore", "test"}) &&
+					cl.CollapseUnknown &&
+					reflect.DeepEqual(cl.Order, changelog.DefaultOrder)
+			},
+		},
+		{
+			config: "template: fancy\n",
+			err:    true,
+		},
+		{
+			config: "unknown_key: true\n",
+			err:    true,
+		},
+		{
+			config: "packages:\n  - {name: svc-a, path: services/a/}\n  - {path: lib, tag_prefix: lib-v, version_file: VERSION.txt}\n",
+			check: func(cfg *Config) bool {
+				return reflect.DeepEqual(cfg.Packages, []PackageConfig{
+					{Name: "svc-a", Path: "services/a", TagPrefix: "svc-a/v"},
+					{Name: "lib", Path: "lib", TagPrefix: "lib-v", VersionFile: "VERSION.txt"},
+				})
+			},
+		},
+		{
+			config: "packages:\n  - {name: svc-a}\n",
+			err:    true,
+		},
+		{
+			config: "packages:\n  - {path: lib}\n  - {name: lib, path: lib2}\n",
+			err:    true,
+		},
+	}
+
+	for i, row := range table {
+		dir, _ := ioutil.TempDir("", "asdf")
+		if row.config != "" {
+			ioutil.WriteFile(path.Join(dir, defaultConfigFile), []byte(row.config), os.ModePerm)
+		}
+		flagSet := flag.NewFlagSet("", flag.ContinueOnError)
+		for _, f := range append(generateFlags(), globalFlags()...) {
+			f.Apply(flagSet)
+		}
+		flagSet.Parse(row.args)
+		ctx := cli.NewContext(&cli.App{}, flagSet, nil)
+		cfg, err := loadConfig(ctx, dir)
+		if row.err {
+			if err == nil {
+				t.Fatalf("[%d] expected error, got nil", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("[%d] unexpected error: %s", i, err)
+		}
+		if !row.check(cfg) {
+			t.Fatalf("[%d] unexpected config: %#v", i, cfg)
+		}
+	}
+}
+
+func TestLoadConfigExplicitMissing(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "asdf")
+	flagSet := flag.NewFlagSet("", flag.ContinueOnError)
+	for _, f := range globalFlags() {
+		f.Apply(flagSet)
+	}
+	flagSet.Parse([]string{"--config", "nope.yml"})
+	ctx := cli.NewContext(&cli.App{}, flagSet, nil)
+	_, err := loadConfig(ctx, dir)
+	if !os.IsNotExist(err) {
+		t.Fatalf("expected not exist error, got %#v", err)
+	}
+}
+
+func TestConfigBump(t *testing.T) {
+	table := []struct {
+		commits map[string]string
+		version string
+		err     error
+	}{
+		{
+			commits: map[string]string{"perf: faster": ""},
+			version: "1.1.0",
+		},
+		{
+			commits: map[string]string{"docs: readme": "", "chore: cleanup": ""},
+			err:     errNoRelease,
+		},
+		{
+			commits: map[string]string{"docs: readme": "", "fix: bug": ""},
+			version: "1.0.1",
+		},
+		{
+			commits: map[string]string{"fix(security): escape input": ""},
+			version: "1.1.0",
+		},
+	}
+	config := "bump:\n" +
+		"  - {type: perf, change: minor}\n" +
+		"  - {type: docs, change: none}\n" +
+		"  - {type: chore, change: none}\n" +
+		"  - {type: fix, scope: security, change: minor}\n"
+	for i, row := range table {
+		repo := createRepository()
+		ioutil.WriteFile(path.Join(repo, defaultConfigFile), []byte(config), os.ModePerm)
+		for subject, body := range row.commits {
+			createAndCommit(repo, subject, body)
+		}
+		cfg := loadTestConfig(t, repo)
+		_, version, err := generateReleaseAndChangelog(repo, cfg)
+		if err != row.err {
+			t.Fatalf("[%d] expected err %v, got %v", i, row.err, err)
+		}
+		if err == nil && version.String() != row.version {
+			t.Fatalf("[%d] expected %s, got %s", i, row.version, version)
+		}
+	}
+}
+
+func TestLoadConfigGlobalFlags(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "asdf")
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(path.Join(dir, "custom.yml"), []byte("version_file: VERSION.txt\n"), os.ModePerm)
+	ioutil.WriteFile(path.Join(dir, "bad.yml"), []byte("version_file: [\n"), os.ModePerm)
+
+	cfg, err := loadAppConfig("--dir", dir, "--config", "custom.yml", "generate")
+	if err != nil || cfg.VersionFile != "VERSION.txt" {
+		t.Fatalf("expected the config file of the global flag, got %#v: %v", cfg, err)
+	}
+	if _, err = loadAppConfig("--dir", dir, "--config", "bad.yml", "generate"); err == nil {
+		t.Fatal("expected an error for the invalid config file")
+	}
+
+	ioutil.WriteFile(path.Join(dir, "exec.yml"), []byte("git_backend: exec\n"), os.ModePerm)
+	cfg, err = loadAppConfig("--dir", dir, "--config", "exec.yml", "--git-backend", "native", "generate")
+	if err != nil || cfg.gitBackend != repository.NativeBackendKind {
+		t.Fatalf("expected the git backend of the global flag, got %#v: %v", cfg, err)
+	}
+	cfg, err = loadAppConfig("--dir", dir, "--config", "exec.yml", "generate")
+	if err != nil || cfg.gitBackend != repository.ExecBackendKind {
+		t.Fatalf("expected the git backend of the config file, got %#v: %v", cfg, err)
+	}
+}
+
+// loadAppConfig runs a command of an app with the global flags
+// and returns the config the command loaded
+func loadAppConfig(args ...string) (*Config, error) {
+	var cfg *Config
+	var err error
+	app := cli.NewApp()
+	app.Flags = globalFlags()
+	app.Commands = []cli.Command{
+		{
+			Name:  "generate",
+			Flags: generateFlags(),
+			Action: func(c *cli.Context) error {
+				var cwd string
+				cwd, err = getCwd(c)
+				if err == nil {
+					cfg, err = loadConfig(c, cwd)
+				}
+				return nil
+			},
+		},
+	}
+	runErr := app.Run(append([]string{"asdf"}, args...))
+	if runErr != nil {
+		return nil, runErr
+	}
+	return cfg, err
+}
+
+func loadTestConfig(t *testing.T, repo string) *Config {
+	flagSet := flag.NewFlagSet("", flag.ContinueOnError)
+	for _, f := range append(generateFlags(), globalFlags()...) {
+		f.Apply(flagSet)
+	}
+	flagSet.Parse([]string{"--dir", repo})
+	ctx := cli.NewContext(&cli.App{}, flagSet, nil)
+	cfg, err := loadConfig(ctx, repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func TestPackageConfigs(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.Packages = []PackageConfig{
+		newPackage(PackageConfig{Name: "svc-a", Path: "services/a"}),
+		newPackage(PackageConfig{Path: "lib", ChangelogFile: "CHANGES.md"}),
+	}
+	table := []struct {
+		pkg       string
+		names     []string
+		prefixes  []string
+		versions  []string
+		changelog []string
+	}{
+		{
+			names:     []string{"svc-a", "lib"},
+			prefixes:  []string{"svc-a/v", "lib/v"},
+			versions:  []string{"services/a/VERSION", "lib/VERSION"},
+			changelog: []string{"services/a/CHANGELOG.md", "lib/CHANGES.md"},
+		},
+		{
+			pkg:       "svc-a",
+			names:     []string{"svc-a"},
+			prefixes:  []string{"svc-a/v"},
+			versions:  []string{"services/a/VERSION"},
+			changelog: []string{"services/a/CHANGELOG.md"},
+		},
+		{
+			pkg:       "services/a/",
+			names:     []string{"svc-a"},
+			prefixes:  []string{"svc-a/v"},
+			versions:  []string{"services/a/VERSION"},
+			changelog: []string{"services/a/CHANGELOG.md"},
+		},
+		{
+			// not configured
+			pkg:       "tools",
+			names:     []string{"tools"},
+			prefixes:  []string{"tools/v"},
+			versions:  []string{"tools/VERSION"},
+			changelog: []string{"tools/CHANGELOG.md"},
+		},
+	}
+	for i, row := range table {
+		cfg.Package = row.pkg
+		var names, prefixes, versions, changelog []string
+		for _, pkgCfg := range cfg.packageConfigs() {
+			names = append(names, pkgCfg.packageName())
+			prefixes = append(prefixes, pkgCfg.TagPrefix)
+			versions = append(versions, pkgCfg.VersionFile)
+			changelog = append(changelog, pkgCfg.ChangelogFile)
+		}
+		if !reflect.DeepEqual(names, row.names) ||
+			!reflect.DeepEqual(prefixes, row.prefixes) ||
+			!reflect.DeepEqual(versions, row.versions) ||
+			!reflect.DeepEqual(changelog, row.changelog) {
+			t.Fatalf("[%d] unexpected packages %v %v %v %v", i, names, prefixes, versions, changelog)
+		}
+	}
+	cfg.Package = ""
+	cfg.Packages = nil
+	if configs := cfg.packageConfigs(); len(configs) != 1 || configs[0] != cfg {
+		t.Fatalf("expected the config itself, got %v", configs)
+	}
+}
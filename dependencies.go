@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/moolen/asdf/changelog"
+)
+
+// goModule holds the parts of a go.mod file that
+// reference other modules of the repository
+type goModule struct {
+	Path     string
+	Requires []string
+	// Replaces contains the local directories of replace directives
+	Replaces []string
+}
+
+// readGoModule parses the go.mod file in the given directory
+func readGoModule(dir string) (*goModule, error) {
+	file, err := os.Open(path.Join(dir, "go.mod"))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	mod := &goModule{}
+	block := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if block != "" {
+			if fields[0] == ")" {
+				block = ""
+				continue
+			}
+			fields = append([]string{block}, fields...)
+		} else if len(fields) == 2 && fields[1] == "(" {
+			block = fields[0]
+			continue
+		}
+		switch fields[0] {
+		case "module":
+			if len(fields) > 1 {
+				mod.Path = strings.Trim(fields[1], `"`)
+			}
+		case "require":
+			if len(fields) > 1 {
+				mod.Requires = append(mod.Requires, strings.Trim(fields[1], `"`))
+			}
+		case "replace":
+			for i, field := range fields {
+				if field != "=>" || i+1 >= len(fields) {
+					continue
+				}
+				target := fields[i+1]
+				if strings.HasPrefix(target, "./") || strings.HasPrefix(target, "../") {
+					mod.Replaces = append(mod.Replaces, path.Join(dir, target))
+				}
+			}
+		}
+	}
+	return mod, scanner.Err()
+}
+
+// resolveDependencies adds the dependencies between packages that are
+// derived from the go.mod files to the declared ones: a package depends on
+// the sibling modules it requires or replaces with a local directory.
+// The packages are sorted so that every package follows its dependencies
+func (cfg *Config) resolveDependencies(cwd string) error {
+	byName := make(map[string]*PackageConfig)
+	byModule := make(map[string]string)
+	byDir := make(map[string]string)
+	mods := make(map[string]*goModule)
+	for i := range cfg.Packages {
+		pkg := &cfg.Packages[i]
+		byName[pkg.Name] = pkg
+		dir := path.Join(cwd, pkg.Path)
+		byDir[dir] = pkg.Name
+		mod, err := readGoModule(dir)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		mods[pkg.Name] = mod
+		byModule[mod.Path] = pkg.Name
+	}
+	for i := range cfg.Packages {
+		pkg := &cfg.Packages[i]
+		for _, dependency := range pkg.DependsOn {
+			if byName[dependency] == nil {
+				return fmt.Errorf("package %s depends on unknown package %s", pkg.Name, dependency)
+			}
+		}
+		mod, ok := mods[pkg.Name]
+		if !ok {
+			continue
+		}
+		var derived []string
+		for _, require := range mod.Requires {
+			derived = append(derived, byModule[require])
+		}
+		for _, replace := range mod.Replaces {
+			derived = append(derived, byDir[replace])
+		}
+		for _, dependency := range derived {
+			if dependency != "" && dependency != pkg.Name && !contains(pkg.DependsOn, dependency) {
+				pkg.DependsOn = append(pkg.DependsOn, dependency)
+			}
+		}
+	}
+
+	// depth first topological sort, keeping the configured order where possible
+	var sorted []PackageConfig
+	state := make(map[string]int)
+	var visit func(pkg *PackageConfig) error
+	visit = func(pkg *PackageConfig) error {
+		switch state[pkg.Name] {
+		case 1:
+			return fmt.Errorf("dependency cycle at package %s", pkg.Name)
+		case 2:
+			return nil
+		}
+		state[pkg.Name] = 1
+		for _, dependency := range pkg.DependsOn {
+			err := visit(byName[dependency])
+			if err != nil {
+				return err
+			}
+		}
+		state[pkg.Name] = 2
+		sorted = append(sorted, *pkg)
+		return nil
+	}
+	for i := range cfg.Packages {
+		err := visit(&cfg.Packages[i])
+		if err != nil {
+			return err
+		}
+	}
+	cfg.Packages = sorted
+	return nil
+}
+
+// updateDependencies records the dependencies of the package
+// that were released in this run
+func (cfg *Config) updateDependencies(released map[string]*semver.Version) {
+	if cfg.pkg == nil {
+		return
+	}
+	cfg.updated = nil
+	for _, name := range cfg.pkg.DependsOn {
+		if version, ok := released[name]; ok {
+			cfg.updated = append(cfg.updated, changelog.Dependency{
+				Name:    name,
+				Version: version,
+			})
+		}
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadGoModule(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "asdf")
+	gomod := `module example.com/mono/app // the app
+
+go 1.12
+
+require example.com/mono/lib v0.1.0
+
+require (
+	github.com/pkg/errors v0.8.1
+	"example.com/mono/util" v0.0.0 // indirect
+)
+
+replace example.com/mono/lib => ../lib
+
+replace (
+	example.com/mono/util v0.0.0 => ./internal/util
+	github.com/pkg/errors => github.com/pkg/errors v0.9.0
+)
+`
+	ioutil.WriteFile(path.Join(dir, "go.mod"), []byte(gomod), os.ModePerm)
+	mod, err := readGoModule(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := &goModule{
+		Path:     "example.com/mono/app",
+		Requires: []string{"example.com/mono/lib", "github.com/pkg/errors", "example.com/mono/util"},
+		Replaces: []string{path.Join(path.Dir(dir), "lib"), path.Join(dir, "internal/util")},
+	}
+	if !reflect.DeepEqual(mod, expected) {
+		t.Fatalf("expected %#v, got %#v", expected, mod)
+	}
+}
+
+func TestResolveDependencies(t *testing.T) {
+	table := []struct {
+		gomods   map[string]string
+		packages []PackageConfig
+		order    []string
+		deps     map[string][]string
+		err      string
+	}{
+		{
+			// declared dependencies
+			packages: []PackageConfig{
+				{Name: "app", Path: "app", DependsOn: []string{"lib"}},
+				{Name: "lib", Path: "lib"},
+			},
+			order: []string{"lib", "app"},
+			deps:  map[string][]string{"app": {"lib"}},
+		},
+		{
+			// derived from go.mod
+			gomods: map[string]string{
+				"app":  "module example.com/app\nrequire example.com/lib v0.1.0\n",
+				"lib":  "module example.com/lib\nreplace example.com/util => ../util\n",
+				"util": "module example.com/util\n",
+			},
+			packages: []PackageConfig{
+				{Name: "app", Path: "app"},
+				{Name: "lib", Path: "lib"},
+				{Name: "util", Path: "util"},
+			},
+			order: []string{"util", "lib", "app"},
+			deps:  map[string][]string{"app": {"lib"}, "lib": {"util"}},
+		},
+		{
+			packages: []PackageConfig{
+				{Name: "app", Path: "app", DependsOn: []string{"nope"}},
+			},
+			err: "unknown package nope",
+		},
+		{
+			packages: []PackageConfig{
+				{Name: "a", Path: "a", DependsOn: []string{"b"}},
+				{Name: "b", Path: "b", DependsOn: []string{"a"}},
+			},
+			err: "dependency cycle",
+		},
+	}
+	for i, row := range table {
+		dir, _ := ioutil.TempDir("", "asdf")
+		for pkg, gomod := range row.gomods {
+			os.MkdirAll(path.Join(dir, pkg), os.ModePerm)
+			ioutil.WriteFile(path.Join(dir, pkg, "go.mod"), []byte(gomod), os.ModePerm)
+		}
+		cfg := defaultConfig()
+		cfg.Packages = row.packages
+		err := cfg.resolveDependencies(dir)
+		if row.err != "" {
+			if err == nil || !strings.Contains(err.Error(), row.err) {
+				t.Fatalf("[%d] expected error %s, got %v", i, row.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("[%d] unexpected error: %v", i, err)
+		}
+		var order []string
+		deps := make(map[string][]string)
+		for _, pkg := range cfg.Packages {
+			order = append(order, pkg.Name)
+			if len(pkg.DependsOn) > 0 {
+				deps[pkg.Name] = pkg.DependsOn
+			}
+		}
+		if !reflect.DeepEqual(order, row.order) || !reflect.DeepEqual(deps, row.deps) {
+			t.Fatalf("[%d] unexpected packages %v %v", i, order, deps)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import "testing"
+
+func TestUnifiedDiff(t *testing.T) {
+	table := []struct {
+		old  string
+		new  string
+		diff string
+	}{
+		{
+			old:  "a\nb\n",
+			new:  "a\nb\n",
+			diff: "",
+		},
+		{
+			old:  "",
+			new:  "1.0.0",
+			diff: "--- a/f\n+++ b/f\n@@ -0,0 +1 @@\n+1.0.0\n\\ No newline at end of file\n",
+		},
+		{
+			old:  "1.0.0",
+			new:  "1.1.0",
+			diff: "--- a/f\n+++ b/f\n@@ -1 +1 @@\n-1.0.0\n\\ No newline at end of file\n+1.1.0\n\\ No newline at end of file\n",
+		},
+		{
+			old:  "1\n2\n3\n4\n5\n6\n",
+			new:  "new\n1\n2\n3\n4\n5\n6\n",
+			diff: "--- a/f\n+++ b/f\n@@ -1,3 +1,4 @@\n+new\n 1\n 2\n 3\n",
+		},
+		{
+			// changes that are far apart get separate hunks
+			old:  "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			new:  "1\nx\n3\n4\n5\n6\n7\n8\n9\ny\n",
+			diff: "--- a/f\n+++ b/f\n@@ -1,5 +1,5 @@\n 1\n-2\n+x\n 3\n 4\n 5\n@@ -7,4 +7,4 @@\n 7\n 8\n 9\n-10\n+y\n",
+		},
+		{
+			// changes that are close share a hunk
+			old:  "1\n2\n3\n4\n5\n",
+			new:  "x\n2\n3\n4\ny\n",
+			diff: "--- a/f\n+++ b/f\n@@ -1,5 +1,5 @@\n-1\n+x\n 2\n 3\n 4\n-5\n+y\n",
+		},
+	}
+	for i, row := range table {
+		diff := unifiedDiff("a/f", "b/f", []byte(row.old), []byte(row.new))
+		if diff != row.diff {
+			t.Fatalf("[%d] expected\n%s\ngot\n%s", i, row.diff, diff)
+		}
+	}
+}
@@ -7,42 +7,106 @@ import (
 	"os"
 	"path"
 
-	log "github.com/Sirupsen/logrus"
+	log "github.com/sirupsen/logrus"
 
 	"github.com/Masterminds/semver"
 	"github.com/moolen/asdf/changelog"
-	"github.com/moolen/asdf/repository"
 	"github.com/urfave/cli"
 )
 
 var errNoCommits = errors.New("there is nothing to release: no new commits found")
+var errNoRelease = errors.New("there is nothing to release: no commit requires a release")
 var errNoSemverVersion = errors.New("version file does not contain a semver version")
 
-// ReleaseToken is repleaced with the prerelease number
-// If there was no previous release it will starting with 1
+// ReleaseToken is replaced with the prerelease number
+// If there was no previous prerelease it starts with 1
 var ReleaseToken = "{RELEASE_NUMBER}"
 
 // CommitToken is replaced within a release and contains the short commit hash
 var CommitToken = "{COMMIT_SHA}"
 
+// DateToken is replaced within the build metadata with the current date as YYYYMMDD
+var DateToken = "{DATE}"
+
+// EnvToken is replaced within the build metadata with the value of
+// the environment variable NAME, e.g. {ENV:BUILD_NUMBER}
+var EnvToken = "{ENV:NAME}"
+
+// VersionToken is replaced with the released version in the commit message
+var VersionToken = "{VERSION}"
+
+// PackageToken is replaced with the name of the released package in the commit message
+var PackageToken = "{PACKAGE}"
+
+// exitNothingToRelease is returned by a dry-run if there is nothing to release
+const exitNothingToRelease = 10
+
 // generateCommand is a stateful operation
-// It looks for a VERSION file, calculates the
-// changelog based on the commits since this file has changed
+// It looks for the latest release, calculates the
+// changelog based on the commits since then and writes it.
+// The next version is written to the VERSION file unless
+// releases are tracked by tags.
+// In dry-run mode nothing is written, instead the version and a diff
+// of the files is written to stdout.
+// Every package of a monorepo is released on its own,
+// packages without anything to release are skipped.
+// Packages are released after their dependencies and
+// at least patch released if a dependency was released
 func generateCommand(c *cli.Context) error {
-	versionFile := c.String(flagFile)
-	changelogFile := c.String(flagChangelog)
 	cwd, err := getCwd(c)
 	if err != nil {
 		return cli.NewExitError(err, 1)
 	}
-	log.Infof("working in dir: %s", cwd)
-	versionPath := path.Join(cwd, versionFile)
-	changelogfile := path.Join(cwd, changelogFile)
-	execDir(cwd, "git", "fetch", "--all")
-	changelog, nextVersion, err := generateReleaseAndChangelog(cwd, versionFile, changelog.DefaultFormatFunc)
+	cfg, err := loadConfig(c, cwd)
 	if err != nil {
-		return cli.NewExitError(err, 4)
+		return cli.NewExitError(err, 2)
+	}
+	dryRun := c.Bool(flagDryRun)
+	log.Infof("working in dir: %s", cwd)
+	// a dry-run has no side effects, not even on the remote tracking branches
+	if !dryRun {
+		err = cfg.newRepository(cwd).Fetch()
+		if err != nil {
+			log.Warnf("could not fetch the remotes: %v", err)
+		}
 	}
+	packages := cfg.packageConfigs()
+	released := make(map[string]*semver.Version)
+	var skipped error
+	for _, pkgCfg := range packages {
+		pkgCfg.updateDependencies(released)
+		changelog, nextVersion, err := generateReleaseAndChangelog(cwd, pkgCfg)
+		if (err == errNoCommits || err == errNoRelease) && (dryRun || len(packages) > 1) {
+			log.Infof("skipping %s: %v", pkgCfg.packageName(), err)
+			skipped = err
+			continue
+		}
+		if err != nil {
+			return cli.NewExitError(err, 4)
+		}
+		released[pkgCfg.packageName()] = nextVersion
+		err = writeRelease(cwd, pkgCfg, changelog, nextVersion, dryRun)
+		if err != nil {
+			return err
+		}
+	}
+	if len(released) == 0 && dryRun {
+		return cli.NewExitError(skipped, exitNothingToRelease)
+	}
+	if len(released) == 0 {
+		return cli.NewExitError(skipped, 4)
+	}
+	return nil
+}
+
+// writeRelease writes the changelog and version file and publishes the release.
+// The release replaces the section of its version in the changelog or is
+// inserted as the latest release, the preamble of the changelog is kept.
+// The Unreleased section is replaced by the release.
+// In dry-run mode the version and a diff of the files is written to stdout
+func writeRelease(cwd string, cfg *Config, release string, nextVersion *semver.Version, dryRun bool) error {
+	versionPath := path.Join(cwd, cfg.VersionFile)
+	changelogfile := path.Join(cwd, cfg.ChangelogFile)
 	currentChangelog, err := ioutil.ReadFile(changelogfile)
 	_, ok := err.(*os.PathError)
 	if err != nil && !ok {
@@ -51,56 +115,76 @@ func generateCommand(c *cli.Context) error {
 	if nextVersion == nil {
 		return cli.NewExitError(errors.New("could not calculate next version"), 6)
 	}
-	err = ioutil.WriteFile(changelogfile, []byte(fmt.Sprintf("%s\n\n\n%s", changelog, currentChangelog)), os.ModePerm)
+	file := changelog.ParseFile(string(currentChangelog), cfg.Marker)
+	file.Remove(changelog.UnreleasedTitle)
+	file.Set(nextVersion.String(), release)
+	newChangelog := []byte(file.String())
+	newVersion := []byte(nextVersion.String())
+	if dryRun {
+		if cfg.packageName() != "" {
+			fmt.Fprintf(os.Stdout, "package: %s\n", cfg.packageName())
+		}
+		fmt.Fprintf(os.Stdout, "version: %s\n", nextVersion)
+		os.Stdout.WriteString(unifiedDiff("a/"+cfg.ChangelogFile, "b/"+cfg.ChangelogFile, currentChangelog, newChangelog))
+		if cfg.Source != sourceTag {
+			currentVersion, _ := ioutil.ReadFile(versionPath)
+			os.Stdout.WriteString(unifiedDiff("a/"+cfg.VersionFile, "b/"+cfg.VersionFile, currentVersion, newVersion))
+		}
+		return nil
+	}
+	err = ioutil.WriteFile(changelogfile, newChangelog, os.ModePerm)
 	if err != nil {
 		return cli.NewExitError(err, 7)
 	}
-	err = ioutil.WriteFile(versionPath, []byte(nextVersion.String()), os.ModePerm)
+	if cfg.Source != sourceTag {
+		err = ioutil.WriteFile(versionPath, newVersion, os.ModePerm)
+		if err != nil {
+			return cli.NewExitError(err, 8)
+		}
+	}
+	err = publishRelease(cfg.newRepository(cwd), cfg, nextVersion, release)
 	if err != nil {
-		return cli.NewExitError(err, 8)
+		return cli.NewExitError(err, 9)
 	}
 	return nil
 }
 
-func generateReleaseAndChangelog(cwd, versionfile string, formatter changelog.FormatFunc) (string, *semver.Version, error) {
-	versionPath := path.Join(cwd, versionfile)
-	versionFile, err := os.Open(versionPath)
-	defer versionFile.Close()
+func generateReleaseAndChangelog(cwd string, cfg *Config) (string, *semver.Version, error) {
+	repo := cfg.newRepository(cwd)
+	version, revision, err := latestRelease(repo, cwd, cfg)
 	if err != nil {
-		return "", nil, errors.New("version file does not exist, please create one")
+		return "", nil, err
 	}
-	version, err := readVersion(versionFile)
+	revision, err = historyStart(repo, cfg, version, revision)
 	if err != nil {
-		return "", nil, errNoSemverVersion
+		return "", nil, err
 	}
-	log.Infof("found version: %s", version)
-	repo := repository.New(cwd, repository.DefaultMapFunc)
-	latestReleaseCommit, err := repo.LatestChangeOfFile(path.Base(versionPath))
+	commits, err := historySince(repo, revision)
 	if err != nil {
 		return "", nil, err
 	}
-	log.Infof("latest release commit: (%s) %s", latestReleaseCommit.Hash, latestReleaseCommit.Subject)
-	commits, err := repo.GetHistoryUntil(latestReleaseCommit.Hash)
+	log.Infof("found %d commits since last release", len(commits))
+	err = checkReleasable(version, commits, cfg)
 	if err != nil {
 		return "", nil, err
 	}
-	if len(commits) == 0 {
-		return "", nil, errNoCommits
-	}
-	log.Infof("found %d commits since last release commit", len(commits))
-	nextVersion := nextReleaseByChange(version, commits.MaxChange())
+	nextVersion, err := nextVersion(repo, cfg, version, releaseChange(commits, cfg))
 	if err != nil {
 		return "", nil, err
 	}
 	log.Infof("next version: %s", nextVersion.String())
 
-	cl := changelog.New(DefaultTypeMap, formatter)
-	changelog := cl.Create(commits, &nextVersion)
-	return changelog, &nextVersion, nil
+	cl := cfg.newChangelog()
+	cl.PreviousRef = revision
+	changelog, err := cl.Create(commits, nextVersion)
+	if err != nil {
+		return "", nil, err
+	}
+	return changelog, nextVersion, nil
 }
 
 func generateFlags() []cli.Flag {
-	return []cli.Flag{
+	return append([]cli.Flag{
 		cli.StringFlag{
 			Name:  flagFile,
 			Value: "VERSION",
@@ -111,5 +195,27 @@ func generateFlags() []cli.Flag {
 			Value: "CHANGELOG.md",
 			Usage: "file that holds the changelog",
 		},
-	}
+		templateFlag(),
+		cli.BoolFlag{
+			Name:  flagCommit,
+			Usage: "commit the changelog and version file",
+		},
+		cli.StringFlag{
+			Name:  flagCommitMsg,
+			Value: defaultCommitMessage,
+			Usage: "message of the release commit. " + VersionToken + " is replaced with the new version, " + PackageToken + " with the name of the package",
+		},
+		cli.BoolFlag{
+			Name:  flagTag,
+			Usage: "create an annotated tag for the new version. The tag message contains the changelog",
+		},
+		cli.BoolFlag{
+			Name:  flagSign,
+			Usage: "sign the tag with the default GPG key",
+		},
+		cli.BoolFlag{
+			Name:  flagDryRun,
+			Usage: "do not write anything, print the next version and a diff of the files instead. Exits with 0 if there is something to release and " + fmt.Sprint(exitNothingToRelease) + " if there is nothing to release",
+		},
+	}, releaseFlags()...)
 }
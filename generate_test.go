@@ -7,10 +7,12 @@ import (
 	"os"
 	"path"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/Masterminds/semver"
-	"github.com/moolen/asdf/changelog"
+	"github.com/moolen/asdf/repository"
 	"github.com/urfave/cli"
 )
 
@@ -47,7 +49,7 @@ func TestPrepareRepo(t *testing.T) {
 				"breaking(YALA-123): DEDALDSALD":    "",
 				"breaking(YALA-345): 235234":        "",
 			},
-			version: semver.MustParse("1.1.0"),
+			version: semver.MustParse("2.0.0"),
 		},
 		{
 			commits: map[string]string{
@@ -63,7 +65,7 @@ func TestPrepareRepo(t *testing.T) {
 			createAndCommit(repo, subject, body)
 		}
 		fmt.Printf("%#v", path.Join(repo, "VERSION"))
-		changelog, nextVersion, err := generateReleaseAndChangelog(repo, "VERSION", changelog.DefaultFormatFunc)
+		changelog, nextVersion, err := generateReleaseAndChangelog(repo, defaultConfig())
 		if err != row.err {
 			t.Fatalf("[%d]\nexpected %#v\n got %#v", i, row.err, err)
 		}
@@ -143,3 +145,352 @@ func createVersionFile(repo, version string) {
 		panic(err)
 	}
 }
+
+func TestGenerateRelease(t *testing.T) {
+	table := []struct {
+		args    []string
+		subject string
+		tag     string
+	}{
+		{
+			args:    []string{"--commit", "--tag", "--dir"},
+			subject: "1.1.0",
+			tag:     "1.1.0",
+		},
+		{
+			args:    []string{"--commit", "--commit-message", "release {VERSION}", "--dir"},
+			subject: "release 1.1.0",
+		},
+		{
+			args:    []string{"--source", "tag", "--tag-prefix", "", "--commit", "--tag", "--dir"},
+			subject: "1.1.0",
+			tag:     "1.1.0",
+		},
+	}
+	for i, row := range table {
+		flagSet := flag.NewFlagSet("", flag.ContinueOnError)
+		for _, flag := range append(generateFlags(), globalFlags()...) {
+			flag.Apply(flagSet)
+		}
+		repo := createRepository()
+		createAndCommit(repo, "feat: foobar", "")
+		flagSet.Parse(append(row.args, repo))
+		ctx := cli.NewContext(&cli.App{}, flagSet, nil)
+		err := generateCommand(ctx)
+		if err != nil {
+			t.Fatalf("[%d] unexpected error: %v", i, err)
+		}
+		r := repository.New(repo, repository.DefaultMapFunc)
+		head, err := r.GetHistory("HEAD^1..HEAD")
+		if err != nil {
+			t.Fatalf("[%d] unexpected error: %v", i, err)
+		}
+		if head[0].Subject != row.subject {
+			t.Fatalf("[%d] expected release commit %s, got %s", i, row.subject, head[0].Subject)
+		}
+		tag, err := r.LatestVersionTag("")
+		if err != nil {
+			t.Fatalf("[%d] unexpected error: %v", i, err)
+		}
+		if row.tag == "" {
+			if tag.Name != "1.0.0" {
+				t.Fatalf("[%d] unexpected tag %s", i, tag.Name)
+			}
+			continue
+		}
+		if tag.Name != row.tag || tag.Hash != head[0].Hash {
+			t.Fatalf("[%d] expected tag %s at %s, got %s at %s", i, row.tag, head[0].Hash, tag.Name, tag.Hash)
+		}
+	}
+}
+
+func TestGenerateDryRun(t *testing.T) {
+	table := []struct {
+		commits map[string]string
+		stdout  []string
+		err     error
+	}{
+		{
+			err: cli.NewExitError(errNoCommits, exitNothingToRelease),
+		},
+		{
+			commits: map[string]string{
+				"docs: readme": "",
+			},
+			stdout: []string{"version: 1.0.1\n", "+++ b/VERSION\n@@ -1 +1 @@\n-1.0.0\n\\ No newline at end of file\n+1.0.1\n"},
+		},
+		{
+			commits: map[string]string{
+				"feat: foobar": "",
+			},
+			stdout: []string{"version: 1.1.0\n", "--- a/CHANGELOG.md\n+++ b/CHANGELOG.md\n@@ -0,0 +1,9 @@\n+## 1.1.0 (", "+* foobar ("},
+		},
+	}
+	for i, row := range table {
+		flagSet := flag.NewFlagSet("", flag.ContinueOnError)
+		for _, flag := range append(generateFlags(), globalFlags()...) {
+			flag.Apply(flagSet)
+		}
+		repo := createRepository()
+		// a dry-run does not fetch, so an unreachable remote does not matter
+		execDir(repo, "git", "remote", "set-url", "origin", path.Join(repo, "missing"))
+		for subject, body := range row.commits {
+			createAndCommit(repo, subject, body)
+		}
+		flagSet.Parse([]string{"--dry-run", "--dir", repo})
+		ctx := cli.NewContext(&cli.App{}, flagSet, nil)
+		stdout := os.Stdout
+		tempfile, _ := ioutil.TempFile("", "")
+		os.Stdout = tempfile
+		err := generateCommand(ctx)
+		os.Stdout = stdout
+		tempfile.Close()
+		out, _ := ioutil.ReadFile(tempfile.Name())
+		if !reflect.DeepEqual(err, row.err) {
+			t.Fatalf("[%d] expected\n%#v\ngot\n%#v", i, row.err, err)
+		}
+		for _, s := range row.stdout {
+			if !strings.Contains(string(out), s) {
+				t.Fatalf("[%d] expected stdout to contain\n%s\ngot\n%s", i, s, out)
+			}
+		}
+		version, _ := ioutil.ReadFile(path.Join(repo, "VERSION"))
+		if string(version) != "1.0.0" {
+			t.Fatalf("[%d] dry-run modified the version file: %s", i, version)
+		}
+		if _, err := os.Stat(path.Join(repo, "CHANGELOG.md")); !os.IsNotExist(err) {
+			t.Fatalf("[%d] dry-run created the changelog", i)
+		}
+	}
+}
+
+func TestGenerateUnreachableRemote(t *testing.T) {
+	flagSet := flag.NewFlagSet("", flag.ContinueOnError)
+	for _, flag := range append(generateFlags(), globalFlags()...) {
+		flag.Apply(flagSet)
+	}
+	repo := createRepository()
+	execDir(repo, "git", "remote", "set-url", "origin", path.Join(repo, "missing"))
+	createAndCommit(repo, "feat: foobar", "")
+	flagSet.Parse([]string{"--dir", repo})
+	// fetching is best-effort
+	err := generateCommand(cli.NewContext(&cli.App{}, flagSet, nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	version, _ := ioutil.ReadFile(path.Join(repo, "VERSION"))
+	if string(version) != "1.1.0" {
+		t.Fatalf("expected version 1.1.0, got %s", version)
+	}
+}
+
+func TestGenerateExistingChangelog(t *testing.T) {
+	repo := createRepository()
+	preamble := "# Changelog\n\nAll notable changes.\n\n"
+	ioutil.WriteFile(path.Join(repo, "CHANGELOG.md"), []byte(preamble+"## 1.0.0 (2019-01-01)\n\n* initial\n"), os.ModePerm)
+	createAndCommit(repo, "feat: foobar", "")
+	var changelogs []string
+	for i := 0; i < 2; i++ {
+		flagSet := flag.NewFlagSet("", flag.ContinueOnError)
+		for _, flag := range append(generateFlags(), globalFlags()...) {
+			flag.Apply(flagSet)
+		}
+		flagSet.Parse([]string{"--source", "tag", "--tag-prefix", "", "--dir", repo})
+		err := generateCommand(cli.NewContext(&cli.App{}, flagSet, nil))
+		if err != nil {
+			t.Fatalf("[%d] unexpected error: %v", i, err)
+		}
+		changelog, _ := ioutil.ReadFile(path.Join(repo, "CHANGELOG.md"))
+		changelogs = append(changelogs, string(changelog))
+	}
+	if !strings.HasPrefix(changelogs[0], preamble+"## 1.1.0 (") || !strings.HasSuffix(changelogs[0], "\n\n\n## 1.0.0 (2019-01-01)\n\n* initial\n") {
+		t.Fatalf("unexpected changelog:\n%s", changelogs[0])
+	}
+	if changelogs[1] != changelogs[0] {
+		t.Fatalf("expected the release to be replaced, got:\n%s", changelogs[1])
+	}
+}
+
+func TestGenerateTwice(t *testing.T) {
+	repo := createRepository()
+	createAndCommit(repo, "feat: foobar", "")
+	var changelogs []string
+	for i := 0; i < 2; i++ {
+		flagSet := flag.NewFlagSet("", flag.ContinueOnError)
+		for _, flag := range append(generateFlags(), globalFlags()...) {
+			flag.Apply(flagSet)
+		}
+		flagSet.Parse([]string{"--dir", repo})
+		err := generateCommand(cli.NewContext(&cli.App{}, flagSet, nil))
+		if err != nil {
+			t.Fatalf("[%d] unexpected error: %v", i, err)
+		}
+		changelog, _ := ioutil.ReadFile(path.Join(repo, "CHANGELOG.md"))
+		changelogs = append(changelogs, string(changelog))
+	}
+	// the uncommitted version file does not count as a release
+	version, _ := ioutil.ReadFile(path.Join(repo, "VERSION"))
+	if string(version) != "1.1.0" {
+		t.Fatalf("expected version 1.1.0, got %s", version)
+	}
+	if !strings.HasPrefix(changelogs[0], "## 1.1.0 (") || changelogs[1] != changelogs[0] {
+		t.Fatalf("expected the release to be replaced, got:\n%s", changelogs[1])
+	}
+}
+
+func TestGeneratePromotion(t *testing.T) {
+	for _, source := range []string{sourceTag, sourceFile} {
+		repo := createRepository()
+		createAndCommit(repo, "feat: big feature", "")
+		createVersionFile(repo, "1.1.0-rc.1")
+		createAndCommit(repo, "chore(release): 1.1.0-rc.1", "")
+		execDir(repo, "git", "tag", "1.1.0-rc.1")
+		flagSet := flag.NewFlagSet("", flag.ContinueOnError)
+		for _, flag := range append(generateFlags(), globalFlags()...) {
+			flag.Apply(flagSet)
+		}
+		flagSet.Parse([]string{"--source", source, "--tag-prefix", "", "--dir", repo})
+		err := generateCommand(cli.NewContext(&cli.App{}, flagSet, nil))
+		if err != nil {
+			t.Fatalf("[%s] unexpected error: %v", source, err)
+		}
+		version, _ := ioutil.ReadFile(path.Join(repo, "VERSION"))
+		changelog, _ := ioutil.ReadFile(path.Join(repo, "CHANGELOG.md"))
+		if source == sourceFile && string(version) != "1.1.0" {
+			t.Fatalf("[%s] expected version 1.1.0, got %s", source, version)
+		}
+		// the final release lists the commits since the previous final release
+		if !strings.HasPrefix(string(changelog), "## 1.1.0 (") || !strings.Contains(string(changelog), ")\n\n#### Feature\n\n* big feature (") {
+			t.Fatalf("[%s] unexpected changelog:\n%s", source, changelog)
+		}
+	}
+}
+
+func TestGenerateBuildMetadata(t *testing.T) {
+	os.Setenv("ASDF_TEST_BUILD", "123")
+	defer os.Unsetenv("ASDF_TEST_BUILD")
+	flagSet := flag.NewFlagSet("", flag.ContinueOnError)
+	for _, flag := range append(generateFlags(), globalFlags()...) {
+		flag.Apply(flagSet)
+	}
+	repo := createRepository()
+	createAndCommit(repo, "feat: foobar", "")
+	flagSet.Parse([]string{"--build", "build.{ENV:ASDF_TEST_BUILD}.{DATE}", "--commit", "--tag", "--dir", repo})
+	ctx := cli.NewContext(&cli.App{}, flagSet, nil)
+	err := generateCommand(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "1.1.0+build.123." + time.Now().UTC().Format("20060102")
+	version, _ := ioutil.ReadFile(path.Join(repo, "VERSION"))
+	if string(version) != expected {
+		t.Fatalf("expected version file %s, got %s", expected, version)
+	}
+	changelog, _ := ioutil.ReadFile(path.Join(repo, "CHANGELOG.md"))
+	if !strings.HasPrefix(string(changelog), "## "+expected+" (") {
+		t.Fatalf("expected changelog header with %s, got %s", expected, changelog)
+	}
+	tag, err := repository.New(repo, repository.DefaultMapFunc).LatestVersionTag("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tag.Name != expected {
+		t.Fatalf("expected tag %s, got %s", expected, tag.Name)
+	}
+}
+
+func TestGenerateMonorepo(t *testing.T) {
+	repo := createRepository()
+	config := "source: tag\npackages:\n  - {name: svc-a, path: services/a}\n  - {path: lib}\n"
+	ioutil.WriteFile(path.Join(repo, defaultConfigFile), []byte(config), os.ModePerm)
+	createAndCommit(repo, "chore: add config", "")
+	for _, dir := range []string{"services/a", "lib"} {
+		os.MkdirAll(path.Join(repo, dir), os.ModePerm)
+	}
+	createAndCommit(path.Join(repo, "services/a"), "feat: service feature", "")
+	createAndCommit(path.Join(repo, "lib"), "fix: lib fix", "")
+
+	flagSet := flag.NewFlagSet("", flag.ContinueOnError)
+	for _, flag := range append(generateFlags(), globalFlags()...) {
+		flag.Apply(flagSet)
+	}
+	flagSet.Parse([]string{"--commit", "--tag", "--dir", repo})
+	err := generateCommand(cli.NewContext(&cli.App{}, flagSet, nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r := repository.New(repo, repository.DefaultMapFunc)
+	for _, row := range []struct{ prefix, tag, changelog, subject string }{
+		{prefix: "svc-a/v", tag: "svc-a/v0.1.0", changelog: "services/a/CHANGELOG.md", subject: "service feature"},
+		{prefix: "lib/v", tag: "lib/v0.0.1", changelog: "lib/CHANGELOG.md", subject: "lib fix"},
+	} {
+		tag, err := r.LatestVersionTag(row.prefix)
+		if err != nil || tag.Name != row.tag {
+			t.Fatalf("expected tag %s, got %v: %v", row.tag, tag, err)
+		}
+		changelog, _ := ioutil.ReadFile(path.Join(repo, row.changelog))
+		if !strings.Contains(string(changelog), row.subject) || strings.Count(string(changelog), "* ") != 1 {
+			t.Fatalf("unexpected changelog %s:\n%s", row.changelog, changelog)
+		}
+	}
+	history, _ := r.GetHistory("HEAD~2..HEAD")
+	if history[0].Subject != "lib 0.0.1" || history[1].Subject != "svc-a 0.1.0" {
+		t.Fatalf("unexpected release commits %s, %s", history[0].Subject, history[1].Subject)
+	}
+
+	// only lib changed since the release
+	createAndCommit(path.Join(repo, "lib"), "feat: lib feature", "")
+	flagSet = flag.NewFlagSet("", flag.ContinueOnError)
+	for _, flag := range append(nextFlags(), globalFlags()...) {
+		flag.Apply(flagSet)
+	}
+	flagSet.Parse([]string{"--dir", repo})
+	stdout := os.Stdout
+	tempfile, _ := ioutil.TempFile("", "")
+	defer tempfile.Close()
+	os.Stdout = tempfile
+	err = nextCommand(cli.NewContext(&cli.App{}, flagSet, nil))
+	os.Stdout = stdout
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out, _ := ioutil.ReadFile(tempfile.Name())
+	if string(out) != "lib 0.1.0\n" {
+		t.Fatalf("unexpected next versions %q", out)
+	}
+}
+
+func TestGenerateMonorepoDependencies(t *testing.T) {
+	repo := createRepository()
+	config := "source: tag\npackages:\n  - {path: app, depends_on: [lib]}\n  - {path: lib}\n  - {path: other}\n"
+	ioutil.WriteFile(path.Join(repo, defaultConfigFile), []byte(config), os.ModePerm)
+	for _, dir := range []string{"app", "lib", "other"} {
+		os.MkdirAll(path.Join(repo, dir), os.ModePerm)
+		createAndCommit(path.Join(repo, dir), "feat: initial "+dir, "")
+	}
+	for _, tag := range []string{"app/v1.0.0", "lib/v1.0.0", "other/v1.0.0"} {
+		execDir(repo, "git", "tag", tag)
+	}
+	createAndCommit(path.Join(repo, "lib"), "feat: lib feature", "")
+
+	flagSet := flag.NewFlagSet("", flag.ContinueOnError)
+	for _, flag := range append(generateFlags(), globalFlags()...) {
+		flag.Apply(flagSet)
+	}
+	flagSet.Parse([]string{"--commit", "--tag", "--dir", repo})
+	err := generateCommand(cli.NewContext(&cli.App{}, flagSet, nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r := repository.New(repo, repository.DefaultMapFunc)
+	for prefix, expected := range map[string]string{"lib/v": "lib/v1.1.0", "app/v": "app/v1.0.1", "other/v": "other/v1.0.0"} {
+		tag, err := r.LatestVersionTag(prefix)
+		if err != nil || tag.Name != expected {
+			t.Fatalf("expected tag %s, got %v: %v", expected, tag, err)
+		}
+	}
+	changelog, _ := ioutil.ReadFile(path.Join(repo, "app", "CHANGELOG.md"))
+	if !strings.Contains(string(changelog), "#### Dependencies updated\n\n* lib 1.1.0\n") {
+		t.Fatalf("unexpected changelog:\n%s", changelog)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/Masterminds/semver"
+	"github.com/moolen/asdf/changelog"
+	"github.com/moolen/asdf/repository"
+)
+
+var errNoReleases = errors.New("no release found in the history")
+
+// historicRelease is a release found in the history of the repository
+type historicRelease struct {
+	version *semver.Version
+	// ref is the tag or the commit of the release
+	ref  string
+	date time.Time
+}
+
+// historicReleases returns all releases, the oldest release comes first.
+// Depending on the configured source these are the release tags reachable
+// from HEAD or the commits that changed the version in the version file
+func historicReleases(repo *repository.GitRepository, cfg *Config) ([]historicRelease, error) {
+	var releases []historicRelease
+	if cfg.Source == sourceTag {
+		tags, err := repo.VersionTags(cfg.TagPrefix)
+		if err != nil {
+			return nil, err
+		}
+		for i := len(tags) - 1; i >= 0; i-- {
+			commit, err := repo.LookupCommit(tags[i].Hash)
+			if err != nil {
+				return nil, err
+			}
+			releases = append(releases, historicRelease{
+				version: tags[i].Version,
+				ref:     tags[i].Name,
+				date:    commit.Date,
+			})
+		}
+		return releases, nil
+	}
+	commits, err := repo.ChangesOfFile(cfg.VersionFile)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(commits) - 1; i >= 0; i-- {
+		content, err := repo.FileAtRevision(commits[i].Hash, cfg.VersionFile)
+		if err != nil {
+			return nil, err
+		}
+		version, err := readVersion(bytes.NewReader(content))
+		if err != nil {
+			log.Infof("skipping %s: %v", commits[i].Hash, err)
+			continue
+		}
+		if len(releases) > 0 && releases[len(releases)-1].version.Equal(version) {
+			continue
+		}
+		releases = append(releases, historicRelease{
+			version: version,
+			ref:     commits[i].Hash,
+			date:    commits[i].Date,
+		})
+	}
+	return releases, nil
+}
+
+// releaseHistory returns the changelog of every release, the latest release
+// comes first. A release lists the commits since the previous release
+// that are not reverted within the release and is dated by the commit it was made at
+func releaseHistory(cwd string, cfg *Config) ([]*changelog.Release, error) {
+	repo := cfg.newRepository(cwd)
+	history, err := historicReleases(repo, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if len(history) == 0 {
+		return nil, errNoReleases
+	}
+	var releases []*changelog.Release
+	previous := ""
+	for _, historic := range history {
+		revisions := historic.ref
+		if previous != "" {
+			revisions = previous + ".." + historic.ref
+		}
+		commits, err := repo.GetHistory(revisions)
+		if err != nil {
+			return nil, err
+		}
+		commits = commits.WithoutReverted()
+		log.Infof("found %d commits for release %s", len(commits), historic.version)
+		cl := cfg.newChangelog()
+		cl.PreviousRef = previous
+		release := cl.Release(commits, historic.version)
+		release.Package = cfg.packageName()
+		release.Date = historic.date.UTC().Format("2006-01-02")
+		releases = append([]*changelog.Release{release}, releases...)
+		previous = historic.ref
+	}
+	return releases, nil
+}
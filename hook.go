@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/urfave/cli"
+)
+
+// hookMarker identifies the hooks installed by asdf
+const hookMarker = "# installed by asdf"
+
+// commitMsgHook lints the message of every commit.
+// The repository is passed explicitly, so its config file is used
+const commitMsgHook = "#!/bin/sh\n" + hookMarker + "\nexec asdf --" + flagDir + " \"$(git rev-parse --show-toplevel)\" lint --" + flagMessageFile + " \"$1\"\n"
+
+var errHookExists = errors.New("a commit-msg hook exists already, use --force to replace it")
+
+// hookInstall installs the commit-msg hook that lints commit messages.
+// The hooks directory is looked up with git, so core.hooksPath is respected
+func hookInstallCommand(c *cli.Context) error {
+	cwd, err := getCwd(c)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	cfg, err := loadConfig(c, cwd)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	dir, err := cfg.newRepository(cwd).GitPath("hooks")
+	if err != nil {
+		return cli.NewExitError(err, 2)
+	}
+	hook := path.Join(dir, "commit-msg")
+	current, err := ioutil.ReadFile(hook)
+	if err == nil && !strings.Contains(string(current), hookMarker) && !c.Bool(flagForce) {
+		return cli.NewExitError(errHookExists, 3)
+	}
+	err = os.MkdirAll(dir, os.ModePerm)
+	if err != nil {
+		return cli.NewExitError(err, 4)
+	}
+	err = ioutil.WriteFile(hook, []byte(commitMsgHook), 0755)
+	if err == nil {
+		err = os.Chmod(hook, 0755)
+	}
+	if err != nil {
+		return cli.NewExitError(err, 4)
+	}
+	log.Infof("installed %s", hook)
+	return nil
+}
+
+func hookInstallFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.BoolFlag{
+			Name:  flagForce,
+			Usage: "replace an existing commit-msg hook",
+		},
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/moolen/asdf/changelog"
+	"github.com/moolen/asdf/repository"
+)
+
+// urlToken is replaced with the URL of the repository in the provider patterns
+const urlToken = "{URL}"
+
+// LinksConfig defines the URL patterns used to link commits, releases and issues.
+// By default they are derived from the origin remote
+type LinksConfig struct {
+	// Disable turns off all links
+	Disable bool `yaml:"disable"`
+	// Provider is github, gitlab, bitbucket or gitea.
+	// It is detected from the host of the origin remote
+	Provider string `yaml:"provider"`
+	// URL is the web URL of the repository, derived from the origin remote
+	URL string `yaml:"url"`
+	// Commit, Compare, Issue and Ticket override the patterns of the provider
+	Commit  string `yaml:"commit"`
+	Compare string `yaml:"compare"`
+	Issue   string `yaml:"issue"`
+	// Ticket links references like PROJ-123, e.g. https://jira.example.com/browse/{ID}
+	Ticket string `yaml:"ticket"`
+}
+
+// providerLinks contains the URL patterns of the supported providers
+var providerLinks = map[string]changelog.Links{
+	"github": {
+		Commit:  urlToken + "/commit/" + changelog.HashToken,
+		Compare: urlToken + "/compare/" + changelog.PreviousToken + "..." + changelog.CurrentToken,
+		Issue:   urlToken + "/issues/" + changelog.IDToken,
+	},
+	"gitlab": {
+		Commit:  urlToken + "/-/commit/" + changelog.HashToken,
+		Compare: urlToken + "/-/compare/" + changelog.PreviousToken + "..." + changelog.CurrentToken,
+		Issue:   urlToken + "/-/issues/" + changelog.IDToken,
+	},
+	"bitbucket": {
+		Commit:  urlToken + "/commits/" + changelog.HashToken,
+		Compare: urlToken + "/branches/compare/" + changelog.CurrentToken + "%0D" + changelog.PreviousToken,
+		Issue:   urlToken + "/issues/" + changelog.IDToken,
+	},
+	"gitea": {
+		Commit:  urlToken + "/commit/" + changelog.HashToken,
+		Compare: urlToken + "/compare/" + changelog.PreviousToken + "..." + changelog.CurrentToken,
+		Issue:   urlToken + "/issues/" + changelog.IDToken,
+	},
+}
+
+// scpRemotePattern matches remotes like git@github.com:owner/repo.git
+var scpRemotePattern = regexp.MustCompile(`^[\w.-]+@([^:/]+):(.+?)(?:\.git)?/?$`)
+
+// urlRemotePattern matches remotes like https://github.com/owner/repo.git
+// or ssh://git@gitlab.example.com:2222/group/repo.git
+var urlRemotePattern = regexp.MustCompile(`^(?:https?|ssh|git)://(?:[^@/]+@)?([^/:]+)(?::\d+)?/(.+?)(?:\.git)?/?$`)
+
+// remoteWebURL returns the web URL and the host of a remote
+// or empty strings if it is not hosted, e.g. a local path
+func remoteWebURL(remote string) (string, string) {
+	found := scpRemotePattern.FindStringSubmatch(remote)
+	if found == nil {
+		found = urlRemotePattern.FindStringSubmatch(remote)
+	}
+	if found == nil {
+		return "", ""
+	}
+	return "https://" + found[1] + "/" + found[2], found[1]
+}
+
+// providers is the order in which detectProvider
+// looks for the provider names in the host
+var providers = []string{"github", "gitlab", "bitbucket", "gitea"}
+
+// detectProvider returns the first provider whose name is part of the host
+func detectProvider(host string) string {
+	for _, provider := range providers {
+		if strings.Contains(host, provider) {
+			return provider
+		}
+	}
+	return ""
+}
+
+// resolveLinks returns the URL patterns of the configured or detected provider.
+// Explicitly configured patterns take precedence
+func resolveLinks(repo *repository.GitRepository, cfg LinksConfig) changelog.Links {
+	if cfg.Disable {
+		return changelog.Links{}
+	}
+	url, provider := cfg.URL, cfg.Provider
+	if url == "" || provider == "" {
+		remote, err := repo.RemoteURL("origin")
+		if err != nil {
+			log.Debugf("no origin remote: %v", err)
+		}
+		remoteURL, host := remoteWebURL(remote)
+		if url == "" {
+			url = remoteURL
+		}
+		if provider == "" {
+			provider = detectProvider(host)
+		}
+	}
+	var links changelog.Links
+	if patterns, ok := providerLinks[provider]; ok && url != "" {
+		url = strings.TrimSuffix(url, "/")
+		links = changelog.Links{
+			Commit:  strings.Replace(patterns.Commit, urlToken, url, -1),
+			Compare: strings.Replace(patterns.Compare, urlToken, url, -1),
+			Issue:   strings.Replace(patterns.Issue, urlToken, url, -1),
+		}
+	}
+	if cfg.Commit != "" {
+		links.Commit = cfg.Commit
+	}
+	if cfg.Compare != "" {
+		links.Compare = cfg.Compare
+	}
+	if cfg.Issue != "" {
+		links.Issue = cfg.Issue
+	}
+	links.Ticket = cfg.Ticket
+	return links
+}
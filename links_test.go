@@ -0,0 +1,102 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/moolen/asdf/changelog"
+	"github.com/moolen/asdf/repository"
+)
+
+func TestRemoteWebURL(t *testing.T) {
+	table := []struct {
+		remote string
+		url    string
+		host   string
+	}{
+		{remote: "git@github.com:moolen/asdf.git", url: "https://github.com/moolen/asdf", host: "github.com"},
+		{remote: "https://github.com/moolen/asdf", url: "https://github.com/moolen/asdf", host: "github.com"},
+		{remote: "https://user@gitlab.com/group/sub/repo.git", url: "https://gitlab.com/group/sub/repo", host: "gitlab.com"},
+		{remote: "ssh://git@gitea.example.com:2222/owner/repo.git", url: "https://gitea.example.com/owner/repo", host: "gitea.example.com"},
+		{remote: "git@bitbucket.org:team/repo.git", url: "https://bitbucket.org/team/repo", host: "bitbucket.org"},
+		{remote: "/tmp/some/bare/repo"},
+		{remote: ""},
+	}
+	for i, row := range table {
+		url, host := remoteWebURL(row.remote)
+		if url != row.url || host != row.host {
+			t.Fatalf("[%d] expected %s %s, got %s %s", i, row.url, row.host, url, host)
+		}
+	}
+}
+
+func TestDetectProvider(t *testing.T) {
+	table := map[string]string{
+		"github.com":                   "github",
+		"gitlab.example.com":           "gitlab",
+		"bitbucket.org":                "bitbucket",
+		"gitea.example.com":            "gitea",
+		"gitlab.github-mirror.example": "github",
+		"git.example.com":              "",
+	}
+	for host, expected := range table {
+		if provider := detectProvider(host); provider != expected {
+			t.Fatalf("%s: expected %q, got %q", host, expected, provider)
+		}
+	}
+}
+
+func TestResolveLinks(t *testing.T) {
+	repo := createRepository()
+	execDir(repo, "git", "remote", "set-url", "origin", "git@gitlab.example.com:group/repo.git")
+	table := []struct {
+		cfg   LinksConfig
+		links changelog.Links
+	}{
+		{
+			cfg: LinksConfig{},
+			links: changelog.Links{
+				Commit:  "https://gitlab.example.com/group/repo/-/commit/{HASH}",
+				Compare: "https://gitlab.example.com/group/repo/-/compare/{PREVIOUS}...{CURRENT}",
+				Issue:   "https://gitlab.example.com/group/repo/-/issues/{ID}",
+			},
+		},
+		{
+			cfg: LinksConfig{Provider: "gitea", URL: "https://git.example.com/owner/repo/", Ticket: "https://jira.example.com/browse/{ID}"},
+			links: changelog.Links{
+				Commit:  "https://git.example.com/owner/repo/commit/{HASH}",
+				Compare: "https://git.example.com/owner/repo/compare/{PREVIOUS}...{CURRENT}",
+				Issue:   "https://git.example.com/owner/repo/issues/{ID}",
+				Ticket:  "https://jira.example.com/browse/{ID}",
+			},
+		},
+		{
+			cfg: LinksConfig{Issue: "https://tracker.example.com/{ID}"},
+			links: changelog.Links{
+				Commit:  "https://gitlab.example.com/group/repo/-/commit/{HASH}",
+				Compare: "https://gitlab.example.com/group/repo/-/compare/{PREVIOUS}...{CURRENT}",
+				Issue:   "https://tracker.example.com/{ID}",
+			},
+		},
+		{
+			cfg:   LinksConfig{Disable: true},
+			links: changelog.Links{},
+		},
+	}
+	for i, row := range table {
+		links := resolveLinks(repository.New(repo, repository.DefaultMapFunc), row.cfg)
+		if !reflect.DeepEqual(links, row.links) {
+			t.Fatalf("[%d] expected\n%#v\ngot\n%#v", i, row.links, links)
+		}
+	}
+
+	// no provider without a hosted remote
+	dir, _ := ioutil.TempDir("", "asdf")
+	defer os.RemoveAll(dir)
+	links := resolveLinks(repository.New(dir, repository.DefaultMapFunc), LinksConfig{})
+	if !reflect.DeepEqual(links, changelog.Links{}) {
+		t.Fatalf("expected no links, got %#v", links)
+	}
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/moolen/asdf/changelog"
+	"github.com/moolen/asdf/repository"
+	"github.com/urfave/cli"
+)
+
+// defaultMaxHeaderLength is the default length limit of the first line of a commit message
+const defaultMaxHeaderLength = 100
+
+// scissorsLine ends the message in a commit message file written by `git commit --verbose`
+const scissorsLine = "# ------------------------ >8 ------------------------"
+
+// LintConfig defines the rules that commit messages are validated against
+type LintConfig struct {
+	// Types are the allowed commit types. Defaults to the configured Types
+	Types []string `yaml:"types"`
+	// Scopes are the allowed scopes. Any scope is allowed if empty
+	Scopes []string `yaml:"scopes"`
+	// RequireScope rejects commits without a scope
+	RequireScope bool `yaml:"require_scope"`
+	// MaxHeaderLength limits the length of the first line, defaults to 100
+	MaxHeaderLength int `yaml:"max_header_length"`
+	// SubjectCase is the case subjects must be written in: lower, upper or sentence
+	SubjectCase string `yaml:"subject_case"`
+}
+
+// lintProblem is a violated rule of a commit message
+type lintProblem struct {
+	Rule    string
+	Message string
+}
+
+func (p lintProblem) String() string {
+	return p.Rule + ": " + p.Message
+}
+
+var errLintFailed = errors.New("commit messages do not follow the conventions")
+
+// autogeneratedPattern matches the headers git creates itself,
+// these messages are not linted
+var autogeneratedPattern = regexp.MustCompile(`^(Merge |Revert "|fixup! |squash! |amend! )`)
+
+// cleanMessage removes the comments and everything after the scissors line
+// like git does before it creates the commit
+func cleanMessage(message string) string {
+	var lines []string
+	for _, line := range strings.Split(message, "\n") {
+		if line == scissorsLine {
+			break
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, strings.TrimRight(line, " \t\r"))
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// lintTypes returns the allowed commit types
+func (cfg *Config) lintTypes() []string {
+	if len(cfg.Lint.Types) > 0 {
+		return cfg.Lint.Types
+	}
+	var types []string
+	for t := range cfg.Types {
+		if t != changelog.DependenciesType {
+			types = append(types, t)
+		}
+	}
+	sort.Strings(types)
+	return types
+}
+
+// lintMessage validates the commit message against the configured rules
+func (cfg *Config) lintMessage(message string) []lintProblem {
+	message = cleanMessage(message)
+	header := strings.SplitN(message, "\n", 2)[0]
+	if autogeneratedPattern.MatchString(header) {
+		return nil
+	}
+	var problems []lintProblem
+	add := func(rule, format string, args ...interface{}) {
+		problems = append(problems, lintProblem{Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+	if message == "" {
+		add("message-empty", "the commit message is empty")
+		return problems
+	}
+	if length := utf8.RuneCountInString(header); length > cfg.Lint.MaxHeaderLength {
+		add("header-max-length", "the header has %d characters, at most %d are allowed", length, cfg.Lint.MaxHeaderLength)
+	}
+	if lines := strings.SplitN(message, "\n", 3); len(lines) > 1 && lines[1] != "" {
+		add("body-leading-blank", "the body must be separated from the header by an empty line")
+	}
+	msg := repository.DefaultMapFunc(header, "")
+	if msg.Type == "" {
+		add("header-format", "the header must look like \"<type>(<scope>): <subject>\", got %q", header)
+		return problems
+	}
+	types := cfg.lintTypes()
+	if !contains(types, msg.Type) {
+		add("type-enum", "type %q is not allowed, expected one of: %s", msg.Type, strings.Join(types, ", "))
+	}
+	if msg.Scope == "" && cfg.Lint.RequireScope {
+		add("scope-empty", "a scope is required")
+	}
+	if msg.Scope != "" && len(cfg.Lint.Scopes) > 0 && !contains(cfg.Lint.Scopes, msg.Scope) {
+		add("scope-enum", "scope %q is not allowed, expected one of: %s", msg.Scope, strings.Join(cfg.Lint.Scopes, ", "))
+	}
+	if cfg.lintCase.Apply(msg.Subject) != msg.Subject {
+		add("subject-case", "the subject must be in %s case", cfg.lintCase)
+	}
+	return problems
+}
+
+// lint validates commit messages. The messages are read from the
+// --message-file, e.g. in a commit-msg hook, the commits of the
+// --range, e.g. the commits of a pull request, or stdin.
+// Every violated rule is written to stderr
+func lintCommand(c *cli.Context) error {
+	cwd, err := getCwd(c)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	cfg, err := loadConfig(c, cwd)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	type lintMessage struct {
+		name, text string
+	}
+	var messages []lintMessage
+	switch {
+	case c.String(flagRange) != "":
+		// keep the raw header in the subject
+		repo := repository.New(cwd, func(subject, body string) repository.Message {
+			return repository.Message{Subject: subject}
+		})
+		repo.Backend = repository.NewBackend(cfg.gitBackend, cwd)
+		commits, err := repo.GetHistory(c.String(flagRange))
+		if err != nil {
+			return cli.NewExitError(err, 2)
+		}
+		for _, commit := range commits {
+			messages = append(messages, lintMessage{
+				name: changelog.TrimSHA(commit.Hash),
+				text: commit.Subject + "\n\n" + commit.Body,
+			})
+		}
+	default:
+		var text []byte
+		file := c.String(flagMessageFile)
+		if file == "" || file == "-" {
+			text, err = ioutil.ReadAll(os.Stdin)
+		} else {
+			text, err = ioutil.ReadFile(file)
+		}
+		if err != nil {
+			return cli.NewExitError(err, 2)
+		}
+		messages = append(messages, lintMessage{name: file, text: string(text)})
+	}
+	failed := 0
+	for _, message := range messages {
+		problems := cfg.lintMessage(message.text)
+		if len(problems) == 0 {
+			continue
+		}
+		failed++
+		writeLintProblems(os.Stderr, message.name, strings.SplitN(cleanMessage(message.text), "\n", 2)[0], problems)
+	}
+	if failed > 0 {
+		return cli.NewExitError(fmt.Sprintf("%s: %d of %d", errLintFailed, failed, len(messages)), 3)
+	}
+	return nil
+}
+
+// writeLintProblems lists the problems of a message
+func writeLintProblems(w io.Writer, name, header string, problems []lintProblem) {
+	if name != "" && name != "-" {
+		fmt.Fprintf(w, "%s: ", name)
+	}
+	fmt.Fprintf(w, "%s\n", header)
+	for _, problem := range problems {
+		fmt.Fprintf(w, "  %s\n", problem)
+	}
+}
+
+func lintFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  flagMessageFile,
+			Usage: "file containing the commit message, e.g. the first argument of a commit-msg hook. Defaults to stdin",
+		},
+		cli.StringFlag{
+			Name:  flagRange,
+			Usage: "lint the commits of a revision range instead, e.g. \"origin/main..HEAD\"",
+		},
+	}
+}
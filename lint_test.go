@@ -0,0 +1,143 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli"
+)
+
+func TestLintMessage(t *testing.T) {
+	cfg := defaultConfig()
+	err := cfg.parse([]byte("lint:\n  scopes: [api, cli]\n  max_header_length: 30\n  subject_case: lower\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := []struct {
+		message string
+		rules   []string
+	}{
+		{message: "feat(api): new endpoint"},
+		{message: "fix: a bug\n\nwith a body\n\nCloses #12\n"},
+		{message: "feat!: drop v1\n# Please enter the commit message\n" + scissorsLine + "\ndiff --git a/x b/x\n"},
+		{message: "Merge branch 'main' into feature"},
+		{message: "fixup! feat: new endpoint"},
+		{message: "# only comments\n", rules: []string{"message-empty"}},
+		{message: "new endpoint", rules: []string{"header-format"}},
+		{message: "feature: new endpoint", rules: []string{"type-enum"}},
+		{message: "feat(db): new table", rules: []string{"scope-enum"}},
+		{message: "feat: New endpoint", rules: []string{"subject-case"}},
+		{message: "feat: ", rules: []string{"header-format"}},
+		{message: "feat: a very long subject exceeding the limit", rules: []string{"header-max-length"}},
+		{message: "feat: endpoint\nbody", rules: []string{"body-leading-blank"}},
+		{message: "feature(db): New", rules: []string{"type-enum", "scope-enum", "subject-case"}},
+	}
+	for i, row := range table {
+		var rules []string
+		for _, problem := range cfg.lintMessage(row.message) {
+			rules = append(rules, problem.Rule)
+		}
+		if !reflect.DeepEqual(rules, row.rules) {
+			t.Fatalf("[%d] expected rules %v, got %v", i, row.rules, rules)
+		}
+	}
+
+	cfg = defaultConfig()
+	cfg.parse([]byte("types: {ops: Operations}\nlint:\n  require_scope: true\n"))
+	if problems := cfg.lintMessage("ops: deploy"); len(problems) != 1 || problems[0].Rule != "scope-empty" {
+		t.Fatalf("unexpected problems %v", problems)
+	}
+	if problems := cfg.lintMessage("dependencies(api): x"); len(problems) != 1 || problems[0].Rule != "type-enum" {
+		t.Fatalf("unexpected problems %v", problems)
+	}
+}
+
+func TestLintCommand(t *testing.T) {
+	repo := createRepository()
+	createAndCommit(repo, "feat: new endpoint", "")
+	createAndCommit(repo, "did something", "")
+	ioutil.WriteFile(path.Join(repo, "COMMIT_EDITMSG"), []byte("fix: a bug\n"), os.ModePerm)
+	table := []struct {
+		args   []string
+		err    bool
+		stderr string
+	}{
+		{args: []string{"--message-file", path.Join(repo, "COMMIT_EDITMSG")}},
+		{args: []string{"--range", "HEAD~1..HEAD"}, err: true, stderr: ": did something\n  header-format: "},
+		{args: []string{"--range", "HEAD~2..HEAD~1"}},
+		{args: []string{"--message-file", path.Join(repo, "missing")}, err: true},
+	}
+	for i, row := range table {
+		flagSet := flag.NewFlagSet("", flag.ContinueOnError)
+		for _, flag := range append(lintFlags(), globalFlags()...) {
+			flag.Apply(flagSet)
+		}
+		flagSet.Parse(append(row.args, "--dir", repo))
+		stderr := os.Stderr
+		tempfile, _ := ioutil.TempFile("", "")
+		os.Stderr = tempfile
+		err := lintCommand(cli.NewContext(&cli.App{}, flagSet, nil))
+		os.Stderr = stderr
+		tempfile.Close()
+		if (err != nil) != row.err {
+			t.Fatalf("[%d] unexpected error: %v", i, err)
+		}
+		out, _ := ioutil.ReadFile(tempfile.Name())
+		if !strings.Contains(string(out), row.stderr) {
+			t.Fatalf("[%d] expected stderr to contain %q, got %q", i, row.stderr, out)
+		}
+	}
+}
+
+func TestHookInstall(t *testing.T) {
+	repo := createRepository()
+	run := func(args ...string) error {
+		flagSet := flag.NewFlagSet("", flag.ContinueOnError)
+		for _, flag := range append(hookInstallFlags(), globalFlags()...) {
+			flag.Apply(flagSet)
+		}
+		flagSet.Parse(append(args, "--dir", repo))
+		return hookInstallCommand(cli.NewContext(&cli.App{}, flagSet, nil))
+	}
+	hook := path.Join(repo, ".git", "hooks", "commit-msg")
+	ioutil.WriteFile(hook, []byte("#!/bin/sh\nexit 0\n"), 0755)
+	if err := run(); !reflect.DeepEqual(err, cli.NewExitError(errHookExists, 3)) {
+		t.Fatalf("expected existing hook to be kept, got %v", err)
+	}
+	for _, args := range [][]string{{"--force"}, {}} {
+		if err := run(args...); err != nil {
+			t.Fatalf("%v: unexpected error: %v", args, err)
+		}
+		content, _ := ioutil.ReadFile(hook)
+		info, _ := os.Stat(hook)
+		if string(content) != commitMsgHook || info.Mode().Perm() != 0755 {
+			t.Fatalf("%v: unexpected hook %q with mode %v", args, content, info.Mode())
+		}
+	}
+
+	// the installed hook lints with the config of the repository
+	bin, _ := ioutil.TempDir("", "asdf")
+	defer os.RemoveAll(bin)
+	out, err := exec.Command("go", "build", "-o", path.Join(bin, "asdf"), ".").CombinedOutput()
+	if err != nil {
+		t.Fatalf("could not build asdf: %v: %s", err, out)
+	}
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	ioutil.WriteFile(path.Join(repo, defaultConfigFile), []byte("lint:\n  require_scope: true\n"), os.ModePerm)
+	sub := path.Join(repo, "sub")
+	os.Mkdir(sub, os.ModePerm)
+	for message, valid := range map[string]bool{"fix: no scope": false, "fix(lint): with scope": true} {
+		commit := exec.Command("git", "commit", "--allow-empty", "-m", message)
+		commit.Dir = sub
+		if err := commit.Run(); (err == nil) != valid {
+			t.Fatalf("%s: expected valid %t, got %v", message, valid, err)
+		}
+	}
+}
@@ -6,19 +6,48 @@ import (
 	"os/exec"
 	"path"
 
-	log "github.com/Sirupsen/logrus"
+	log "github.com/sirupsen/logrus"
 
+	"github.com/moolen/asdf/repository"
 	"github.com/urfave/cli"
 )
 
 const (
-	flagRevision  = "revision"
-	flagDir       = "dir"
-	flagFile      = "file"
-	flagChangelog = "changelog"
-	flagLatest    = "latest"
-	flagVersion   = "version"
-	flagDebug     = "debug"
+	flagRevision       = "revision"
+	flagDir            = "dir"
+	flagFile           = "file"
+	flagChangelog      = "changelog"
+	flagLatest         = "latest"
+	flagVersion        = "version"
+	flagDebug          = "debug"
+	flagConfig         = "config"
+	flagSource         = "source"
+	flagTagPrefix      = "tag-prefix"
+	flagCommit         = "commit"
+	flagCommitMsg      = "commit-message"
+	flagTag            = "tag"
+	flagSign           = "sign"
+	flagDryRun         = "dry-run"
+	flagPrerelease     = "prerelease"
+	flagBuild          = "build"
+	flagInitialDev     = "initial-development"
+	flagReleaseAs      = "release-as"
+	flagPackage        = "package"
+	flagFormat         = "format"
+	flagTemplate       = "template"
+	flagAll            = "all"
+	flagMessageFile    = "message-file"
+	flagRange          = "range"
+	flagForce          = "force"
+	flagType           = "type"
+	flagScope          = "scope"
+	flagSubject        = "subject"
+	flagBody           = "body"
+	flagBreaking       = "breaking"
+	flagCloses         = "closes"
+	flagNonInteractive = "non-interactive"
+	flagHistory        = "history"
+	flagGitBackend     = "git-backend"
 )
 
 var errNoRevision = errors.New("revision is required")
@@ -26,7 +55,8 @@ var errNoFile = errors.New("file is required")
 var errNoVersionProvided = errors.New("no version provided")
 
 // DefaultTypeMap contains a mapping of types to groups
-// which are used to render the changelog
+// which are used to render the changelog.
+// Additional types may be defined in the config file
 var DefaultTypeMap = map[string]string{
 	"feat":     "Feature",
 	"breaking": "Breaking Changes",
@@ -37,6 +67,8 @@ var DefaultTypeMap = map[string]string{
 	"refactor": "Code Refactoring",
 	"test":     "Tests",
 	"chore":    "Chores",
+	// lists the updated packages of a monorepo
+	"dependencies": "Dependencies updated",
 }
 
 func main() {
@@ -79,6 +111,42 @@ func main() {
 			Flags:   changelogFlags(),
 			Action:  changelogCommand,
 		},
+		{
+			Name:   "regenerate",
+			Usage:  "rebuilds the changelog file from the full history: every release tag or change of the version file gets its own section",
+			Flags:  regenerateFlags(),
+			Action: regenerateCommand,
+		},
+		{
+			Name:   "unreleased",
+			Usage:  "updates the Unreleased section of the changelog file with the commits since the latest release. generate turns it into the section of the new version",
+			Flags:  unreleasedFlags(),
+			Action: unreleasedCommand,
+		},
+		{
+			Name:   "lint",
+			Usage:  "validates commit messages of a --" + flagMessageFile + ", a --" + flagRange + " of commits or stdin against the conventions",
+			Flags:  lintFlags(),
+			Action: lintCommand,
+		},
+		{
+			Name:   "commit",
+			Usage:  "composes a conventional commit message and commits the staged changes. Missing parts are asked for",
+			Flags:  commitFlags(),
+			Action: commitCommand,
+		},
+		{
+			Name:  "hook",
+			Usage: "manages git hooks",
+			Subcommands: []cli.Command{
+				{
+					Name:   "install",
+					Usage:  "installs a commit-msg hook that lints every commit message",
+					Flags:  hookInstallFlags(),
+					Action: hookInstallCommand,
+				},
+			},
+		},
 	}
 
 	app.Before = func(c *cli.Context) error {
@@ -104,11 +172,21 @@ func globalFlags() []cli.Flag {
 			Name:  flagDebug,
 			Usage: "show debug logs",
 		},
+		cli.StringFlag{
+			Name:  flagConfig,
+			Value: "",
+			Usage: "config file to use. Defaults to " + defaultConfigFile + " in the working directory",
+		},
+		cli.StringFlag{
+			Name:  flagGitBackend,
+			Value: string(repository.AutoBackend),
+			Usage: "access git with the git binary (exec), natively without it (native) or auto",
+		},
 	}
 }
 
 func getCwd(c *cli.Context) (string, error) {
-	cwd := c.String("dir")
+	cwd := c.GlobalString(flagDir)
 	if cwd == "" {
 		dir, err := os.Executable()
 		if err != nil {
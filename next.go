@@ -1,58 +1,143 @@
 package main
 
 import (
+	"fmt"
+	"io"
 	"os"
-	"path"
 
-	log "github.com/Sirupsen/logrus"
+	log "github.com/sirupsen/logrus"
 
+	"github.com/Masterminds/semver"
+	"github.com/moolen/asdf/changelog"
 	"github.com/moolen/asdf/repository"
 	"github.com/urfave/cli"
 )
 
+const flagExplain = "explain"
+
+// nextCommand writes the next version to stdout.
+// For a monorepo the name and the next version of every package
+// is written on a line, packages without anything to release are skipped
 func nextCommand(c *cli.Context) error {
-	var commit *repository.Commit
-	file := c.String(flagFile)
 	cwd, err := getCwd(c)
 	if err != nil {
 		return cli.NewExitError(err, 1)
 	}
-	if file == "" {
-		return cli.NewExitError(errNoFile, 2)
-	}
-	file = path.Join(cwd, file)
-	repo := repository.New(cwd, repository.DefaultMapFunc)
-	commit, err = repo.LatestChangeOfFile(file)
+	cfg, err := loadConfig(c, cwd)
 	if err != nil {
-		return cli.NewExitError(err, 3)
+		return cli.NewExitError(err, 1)
+	}
+	packages := cfg.packageConfigs()
+	if len(packages) == 1 {
+		version, code, err := packageNextVersion(cwd, packages[0], c.Bool(flagExplain))
+		if err != nil {
+			return cli.NewExitError(err, code)
+		}
+		os.Stdout.WriteString(version.String())
+		return nil
 	}
-	log.Infof("file %s had last change at %s in commit %s", file, commit.Date.Format("2006-01-02"), commit.Hash)
-	latest, err := readVersionFile(file)
-	log.Infof("found version: %s", latest)
+	released := make(map[string]*semver.Version)
+	for _, pkgCfg := range packages {
+		pkgCfg.updateDependencies(released)
+		version, code, err := packageNextVersion(cwd, pkgCfg, c.Bool(flagExplain))
+		if err == errNoCommits || err == errNoRelease {
+			log.Infof("skipping %s: %v", pkgCfg.packageName(), err)
+			continue
+		}
+		if err != nil {
+			return cli.NewExitError(err, code)
+		}
+		released[pkgCfg.packageName()] = version
+		fmt.Fprintf(os.Stdout, "%s %s\n", pkgCfg.packageName(), version)
+	}
+	return nil
+}
+
+// packageNextVersion returns the next version or the error and its exit code
+func packageNextVersion(cwd string, cfg *Config, explain bool) (*semver.Version, int, error) {
+	if cfg.Source == sourceFile && cfg.VersionFile == "" {
+		return nil, 2, errNoFile
+	}
+	repo := cfg.newRepository(cwd)
+	latest, revision, err := latestRelease(repo, cwd, cfg)
 	if err != nil {
-		return cli.NewExitError(err, 4)
+		return nil, 3, err
 	}
-	commits, err := repo.GetHistoryUntil(commit.Hash)
+	revision, err = historyStart(repo, cfg, latest, revision)
 	if err != nil {
-		return cli.NewExitError(err, 5)
+		return nil, 3, err
 	}
-	if len(commits) == 0 {
-		return cli.NewExitError(errNoCommits, 6)
+	commits, err := historySince(repo, revision)
+	if err != nil {
+		return nil, 5, err
 	}
-	log.Infof("commits since last change: %d", len(commits))
+	log.Infof("commits since last release: %d", len(commits))
 
 	log.Infof("found max change: %s", commits.MaxChange())
-	nextVersion := nextReleaseByChange(latest, commits.MaxChange())
-	os.Stdout.WriteString(nextVersion.String())
-	return nil
+	if explain && len(commits) > 0 {
+		if cfg.packageName() != "" {
+			fmt.Fprintf(os.Stderr, "%s:\n", cfg.packageName())
+		}
+		explainChanges(os.Stderr, commits)
+	}
+	err = checkReleasable(latest, commits, cfg)
+	if err != nil {
+		return nil, 6, err
+	}
+	nextVersion, err := nextVersion(repo, cfg, latest, releaseChange(commits, cfg))
+	if err != nil {
+		return nil, 7, err
+	}
+	return nextVersion, 0, nil
+}
+
+// explainChanges writes the commits grouped by the change they cause.
+// The first group determines the next version
+func explainChanges(w io.Writer, commits repository.Commits) {
+	impact := commits.Impact()
+	fmt.Fprintf(w, "%s change caused by %d of %d commits\n", impact.Change, len(impact.Commits), len(commits))
+	for _, change := range []repository.Change{
+		repository.MajorChange,
+		repository.MinorChange,
+		repository.PatchChange,
+		repository.NoChange,
+	} {
+		group := commits.ByChange(change)
+		if len(group) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "%s:\n", change)
+		for _, commit := range group {
+			fmt.Fprintf(w, "  %s %s\n", changelog.TrimSHA(commit.Hash), describeCommit(commit))
+		}
+	}
+}
+
+// describeCommit returns the commit header in the conventional commits format
+func describeCommit(commit *repository.Commit) string {
+	header := commit.Type
+	if commit.Scope != "" {
+		header += "(" + commit.Scope + ")"
+	}
+	if commit.Breaking {
+		header += "!"
+	}
+	if header == "" {
+		return commit.Subject
+	}
+	return header + ": " + commit.Subject
 }
 
 func nextFlags() []cli.Flag {
-	return []cli.Flag{
+	return append([]cli.Flag{
 		cli.StringFlag{
 			Name:  flagFile,
 			Value: "VERSION",
 			Usage: "file to use to get the commit of last modification. That file must include the latest version",
 		},
-	}
+		cli.BoolFlag{
+			Name:  flagExplain,
+			Usage: "explain which commits cause the change of the next version. The explanation is written to stderr",
+		},
+	}, releaseFlags()...)
 }
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"flag"
 	"io/ioutil"
 	"os"
@@ -8,15 +9,17 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/moolen/asdf/repository"
 	"github.com/urfave/cli"
 )
 
 func TestNextCommand(t *testing.T) {
 	table := []struct {
-		commits map[string]string
-		stdout  string
-		args    []string
-		err     error
+		commits     map[string]string
+		versionFile string
+		stdout      string
+		args        []string
+		err         error
 	}{
 		{
 			args:   []string{"--dir"},
@@ -44,10 +47,78 @@ func TestNextCommand(t *testing.T) {
 				"fix: bar":  "yolo",
 				"feat: bar": "test",
 			},
-			args:   []string{"--file", "MYVERSIONFILE", "--dir"},
-			stdout: "13.15.0",
+			versionFile: "MYVERSIONFILE",
+			args:        []string{"--file", "MYVERSIONFILE", "--dir"},
+			stdout:      "13.15.0",
+			err:         nil,
+		},
+		{
+			commits: map[string]string{
+				"fix: bar":  "yolo",
+				"feat: bar": "test",
+			},
+			args:   []string{"--explain", "--dir"},
+			stdout: "1.1.0",
+			err:    nil,
+		},
+		{
+			// the tag 1.0.0 points to the initial commit
+			commits: map[string]string{
+				"fix: bar": "yolo",
+			},
+			args:   []string{"--source", "tag", "--tag-prefix", "", "--dir"},
+			stdout: "1.0.1",
 			err:    nil,
 		},
+		{
+			// no tag with that prefix: the whole history is considered
+			commits: map[string]string{
+				"feat: bar": "yolo",
+			},
+			args:   []string{"--source", "tag", "--tag-prefix", "v", "--dir"},
+			stdout: "0.1.0",
+			err:    nil,
+		},
+		{
+			// breaking changes bump the minor version during the initial development
+			commits: map[string]string{
+				"feat!: bar": "yolo",
+			},
+			args:   []string{"--source", "tag", "--tag-prefix", "v", "--initial-development", "--dir"},
+			stdout: "0.1.0",
+			err:    nil,
+		},
+		{
+			commits: map[string]string{
+				"feat: bar": "yolo",
+			},
+			args:   []string{"--source", "tag", "--tag-prefix", "v", "--initial-development", "--dir"},
+			stdout: "0.0.1",
+			err:    nil,
+		},
+		{
+			// not in the initial development anymore
+			commits: map[string]string{
+				"feat!: bar": "yolo",
+			},
+			args:   []string{"--initial-development", "--dir"},
+			stdout: "2.0.0",
+			err:    nil,
+		},
+		{
+			// an explicit version does not require commits
+			args:   []string{"--release-as", "3.0.0", "--dir"},
+			stdout: "3.0.0",
+			err:    nil,
+		},
+		{
+			commits: map[string]string{
+				"feat: bar": "yolo",
+			},
+			args:   []string{"--release-as", "1.0.0", "--dir"},
+			stdout: "",
+			err:    cli.NewExitError(errReleaseAsNotGreater, 7),
+		},
 	}
 
 	for i, row := range table {
@@ -57,7 +128,10 @@ func TestNextCommand(t *testing.T) {
 			flag.Apply(flagSet)
 		}
 		repo := createRepository()
-		ioutil.WriteFile(path.Join(repo, "MYVERSIONFILE"), []byte("13.14.15"), os.ModePerm)
+		if row.versionFile != "" {
+			ioutil.WriteFile(path.Join(repo, row.versionFile), []byte("13.14.15"), os.ModePerm)
+			createAndCommit(repo, "chore: add version file", "")
+		}
 		for subject, body := range row.commits {
 			createAndCommit(repo, subject, body)
 		}
@@ -80,3 +154,140 @@ func TestNextCommand(t *testing.T) {
 
 	}
 }
+
+func TestExplainChanges(t *testing.T) {
+	commits := repository.Commits{
+		{Hash: "aaaaaaaaaa", Type: "fix", Subject: "old bug", Change: repository.PatchChange},
+		{Hash: "bbbbbbbbbb", Type: "feat", Scope: "api", Subject: "new endpoint", Change: repository.MinorChange},
+		{Hash: "cccccccccc", Type: "docs", Subject: "readme", Change: repository.NoChange},
+		{Hash: "dddddddddd", Type: "feat", Subject: "another", Change: repository.MinorChange},
+	}
+	var buf bytes.Buffer
+	explainChanges(&buf, commits)
+	expected := "minor change caused by 2 of 4 commits\n" +
+		"minor:\n" +
+		"  bbbbbbbb feat(api): new endpoint\n" +
+		"  dddddddd feat: another\n" +
+		"patch:\n" +
+		"  aaaaaaaa fix: old bug\n" +
+		"none:\n" +
+		"  cccccccc docs: readme\n"
+	if buf.String() != expected {
+		t.Fatalf("expected\n%s\ngot\n%s", expected, buf.String())
+	}
+}
+
+func TestNextCommandPrerelease(t *testing.T) {
+	repo := createRepository()
+	next := func(args ...string) (string, error) {
+		flagSet := flag.NewFlagSet("", flag.ContinueOnError)
+		for _, flag := range append(nextFlags(), globalFlags()...) {
+			flag.Apply(flagSet)
+		}
+		flagSet.Parse(append(args, "--source", "tag", "--tag-prefix", "", "--dir", repo))
+		ctx := cli.NewContext(&cli.App{}, flagSet, nil)
+		stdout := os.Stdout
+		tempfile, _ := ioutil.TempFile("", "")
+		defer tempfile.Close()
+		os.Stdout = tempfile
+		err := nextCommand(ctx)
+		os.Stdout = stdout
+		out, _ := ioutil.ReadFile(tempfile.Name())
+		return string(out), err
+	}
+	expect := func(expected string, args ...string) {
+		out, err := next(args...)
+		if err != nil {
+			t.Fatalf("%v: unexpected error %v", args, err)
+		}
+		if out != expected {
+			t.Fatalf("%v: expected %s, got %s", args, expected, out)
+		}
+	}
+
+	createAndCommit(repo, "feat: foo", "")
+	expect("1.1.0-rc.1", "--prerelease", "rc.{RELEASE_NUMBER}")
+	execDir(repo, "git", "tag", "1.1.0-rc.1")
+	createAndCommit(repo, "fix: bar", "")
+	expect("1.1.0-rc.2", "--prerelease", "rc.{RELEASE_NUMBER}")
+	execDir(repo, "git", "tag", "1.1.0-rc.2")
+
+	// promote without new commits and without a double bump
+	expect("1.1.0")
+
+	// a breaking change after a minor prerelease bumps again
+	createAndCommit(repo, "feat!: baz", "")
+	expect("2.0.0-rc.1", "--prerelease", "rc.{RELEASE_NUMBER}")
+	expect("2.0.0")
+}
+
+func TestNextVersionReverts(t *testing.T) {
+	repo := createRepository()
+	createAndCommit(repo, "feat: reverted feature", "")
+	execDir(repo, "git", "revert", "--no-edit", "HEAD")
+	createAndCommit(repo, "fix: released fix", "")
+	cfg := loadTestConfig(t, repo)
+	changelog, version, err := generateReleaseAndChangelog(repo, cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if version.String() != "1.0.1" || bytes.Contains([]byte(changelog), []byte("reverted feature")) {
+		t.Fatalf("expected the feature and its revert to cancel out, got %s:\n%s", version, changelog)
+	}
+
+	// reverting a released commit is listed
+	createVersionFile(repo, "1.0.1")
+	createAndCommit(repo, "chore(release): 1.0.1", "")
+	execDir(repo, "git", "revert", "--no-edit", "HEAD~1")
+	changelog, version, err = generateReleaseAndChangelog(repo, cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if version.String() != "1.0.2" || !bytes.Contains([]byte(changelog), []byte("#### Reverted\n\n* fix: released fix (")) {
+		t.Fatalf("expected the revert to be listed, got %s:\n%s", version, changelog)
+	}
+}
+
+func TestNextVersionNativeBackend(t *testing.T) {
+	repo := createRepository()
+	execDir(repo, "git", "tag", "v1.0.0")
+	createAndCommit(repo, "feat(api): add endpoint", "Closes #12")
+	createAndCommit(repo, "fix: handle error", "")
+	for _, source := range []string{sourceFile, sourceTag} {
+		cfg := loadTestConfig(t, repo)
+		cfg.Source = source
+		cfg.TagPrefix = "v"
+		expectedChangelog, expectedVersion, err := generateReleaseAndChangelog(repo, cfg)
+		if err != nil {
+			t.Fatalf("[%s] unexpected error: %v", source, err)
+		}
+		cfg.gitBackend = repository.NativeBackendKind
+		changelog, version, err := generateReleaseAndChangelog(repo, cfg)
+		if err != nil {
+			t.Fatalf("[%s] unexpected error: %v", source, err)
+		}
+		if version.String() != "1.1.0" || !version.Equal(expectedVersion) || changelog != expectedChangelog {
+			t.Fatalf("[%s] expected %s:\n%s\ngot %s:\n%s", source, expectedVersion, expectedChangelog, version, changelog)
+		}
+	}
+}
+
+func TestNextVersionDefaultTagPrefix(t *testing.T) {
+	repo := createRepository()
+	createAndCommit(repo, "feat: foo", "")
+	execDir(repo, "git", "tag", "v1.4.2")
+	createAndCommit(repo, "fix: bar", "")
+	flagSet := flag.NewFlagSet("", flag.ContinueOnError)
+	for _, flag := range append(nextFlags(), globalFlags()...) {
+		flag.Apply(flagSet)
+	}
+	flagSet.Parse([]string{"--source", "tag", "--dir", repo})
+	cfg, err := loadConfig(cli.NewContext(&cli.App{}, flagSet, nil), repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	version, _, err := packageNextVersion(repo, cfg, false)
+	if err != nil || version.String() != "1.4.3" {
+		t.Fatalf("expected 1.4.3, got %s: %v", version, err)
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/moolen/asdf/changelog"
+	"github.com/urfave/cli"
+)
+
+// regenerate rebuilds the changelog file of every package
+// from the releases in the history and replaces it atomically.
+// The preamble of the changelog file is kept.
+// In dry-run mode a diff of the changelog is written to stdout
+func regenerateCommand(c *cli.Context) error {
+	cwd, err := getCwd(c)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	cfg, err := loadConfig(c, cwd)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	log.Infof("working in dir: %s", cwd)
+	packages := cfg.packageConfigs()
+	for _, pkgCfg := range packages {
+		releases, err := releaseHistory(cwd, pkgCfg)
+		if err == errNoReleases && len(packages) > 1 {
+			log.Infof("skipping %s: %v", pkgCfg.packageName(), err)
+			continue
+		}
+		if err != nil {
+			return cli.NewExitError(err, 2)
+		}
+		changelogfile := path.Join(cwd, pkgCfg.ChangelogFile)
+		currentChangelog, err := ioutil.ReadFile(changelogfile)
+		if err != nil && !os.IsNotExist(err) {
+			return cli.NewExitError(err, 3)
+		}
+		file := changelog.ParseFile(string(currentChangelog), pkgCfg.Marker)
+		file.Sections = nil
+		cl := pkgCfg.newChangelog()
+		for i := len(releases) - 1; i >= 0; i-- {
+			section, err := cl.Markdown(releases[i])
+			if err != nil {
+				return cli.NewExitError(err, 3)
+			}
+			file.Set(releases[i].Version, section)
+		}
+		newChangelog := []byte(file.String())
+		if c.Bool(flagDryRun) {
+			if pkgCfg.packageName() != "" {
+				fmt.Fprintf(os.Stdout, "package: %s\n", pkgCfg.packageName())
+			}
+			os.Stdout.WriteString(unifiedDiff("a/"+pkgCfg.ChangelogFile, "b/"+pkgCfg.ChangelogFile, currentChangelog, newChangelog))
+			continue
+		}
+		err = writeFileAtomic(changelogfile, newChangelog)
+		if err != nil {
+			return cli.NewExitError(err, 4)
+		}
+		log.Infof("wrote %d releases to %s", len(releases), changelogfile)
+	}
+	return nil
+}
+
+// writeFileAtomic replaces the file with the data. The data is written to a
+// temporary file in the same directory first, which is renamed to the file,
+// so the file is never left behind partially written
+func writeFileAtomic(filename string, data []byte) error {
+	mode := os.ModePerm
+	if info, err := os.Stat(filename); err == nil {
+		mode = info.Mode()
+	}
+	tmp, err := ioutil.TempFile(path.Dir(filename), "."+path.Base(filename))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	err = os.Chmod(tmp.Name(), mode)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
+
+func regenerateFlags() []cli.Flag {
+	return append([]cli.Flag{
+		cli.StringFlag{
+			Name:  flagFile,
+			Value: "VERSION",
+			Usage: "file that holds the version information. Every change of its version is a release",
+		},
+		cli.StringFlag{
+			Name:  flagChangelog,
+			Value: "CHANGELOG.md",
+			Usage: "file that is replaced with the changelog of all releases",
+		},
+		templateFlag(),
+		cli.BoolFlag{
+			Name:  flagDryRun,
+			Usage: "do not write anything, print a diff of the changelog instead",
+		},
+	}, selectFlags(releaseFlags(), flagSource, flagTagPrefix, flagHistory, flagPackage)...)
+}
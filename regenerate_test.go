@@ -0,0 +1,92 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/moolen/asdf/changelog"
+	"github.com/urfave/cli"
+)
+
+func createRelease(repo, version, date string) {
+	os.Setenv("GIT_AUTHOR_DATE", date)
+	defer os.Unsetenv("GIT_AUTHOR_DATE")
+	createVersionFile(repo, version)
+	createAndCommit(repo, "chore(release): "+version, "")
+	execDir(repo, "git", "tag", version)
+}
+
+func createHistory() string {
+	repo := createRepository()
+	createAndCommit(repo, "feat: first feature", "")
+	createRelease(repo, "1.1.0", "2020-01-02T12:00:00Z")
+	createAndCommit(repo, "fix: first fix", "")
+	createRelease(repo, "1.1.1", "2020-02-03T12:00:00Z")
+	createAndCommit(repo, "feat: unreleased feature", "")
+	return repo
+}
+
+func TestRegenerateCommand(t *testing.T) {
+	table := []struct {
+		args []string
+	}{
+		{args: []string{}},
+		{args: []string{"--source", "tag", "--tag-prefix", ""}},
+	}
+	for i, row := range table {
+		repo := createHistory()
+		ioutil.WriteFile(path.Join(repo, "CHANGELOG.md"), []byte("# Changelog\n\n## 0.1.0 (2019-01-01)\n\noutdated\n"), os.ModePerm)
+		flagSet := flag.NewFlagSet("", flag.ContinueOnError)
+		for _, flag := range append(regenerateFlags(), globalFlags()...) {
+			flag.Apply(flagSet)
+		}
+		flagSet.Parse(append(row.args, "--dir", repo))
+		err := regenerateCommand(cli.NewContext(&cli.App{}, flagSet, nil))
+		if err != nil {
+			t.Fatalf("[%d] unexpected error: %v", i, err)
+		}
+		out, _ := ioutil.ReadFile(path.Join(repo, "CHANGELOG.md"))
+		file := changelog.ParseFile(string(out), changelog.DefaultMarker)
+		if file.Preamble != "# Changelog\n\n" || len(file.Sections) != 3 {
+			t.Fatalf("[%d] expected the preamble and 3 releases, got:\n%s", i, out)
+		}
+		var releases []string
+		for _, section := range file.Sections {
+			releases = append(releases, section.Text)
+		}
+		if !strings.HasPrefix(releases[0], "## 1.1.1 (2020-02-03)") || !strings.Contains(releases[0], "* first fix") ||
+			strings.Contains(releases[0], "first feature") || strings.Contains(releases[0], "unreleased") {
+			t.Fatalf("[%d] unexpected release 1.1.1:\n%s", i, releases[0])
+		}
+		if !strings.HasPrefix(releases[1], "## 1.1.0 (2020-01-02)") || !strings.Contains(releases[1], "* first feature") ||
+			strings.Contains(releases[1], "first fix") {
+			t.Fatalf("[%d] unexpected release 1.1.0:\n%s", i, releases[1])
+		}
+		if !strings.HasPrefix(releases[2], "## 1.0.0 (") || !strings.Contains(releases[2], "* initial commit") {
+			t.Fatalf("[%d] unexpected release 1.0.0:\n%s", i, releases[2])
+		}
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "asdf")
+	filename := path.Join(dir, "CHANGELOG.md")
+	ioutil.WriteFile(filename, []byte("old"), 0640)
+	err := writeFileAtomic(filename, []byte("new"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out, _ := ioutil.ReadFile(filename)
+	info, _ := os.Stat(filename)
+	if string(out) != "new" || info.Mode() != 0640 {
+		t.Fatalf("unexpected file %q with mode %v", out, info.Mode())
+	}
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != 1 {
+		t.Fatalf("expected no temporary files, got %d files", len(files))
+	}
+}
@@ -11,11 +11,8 @@ RELEASE=release-$NEXT_VERSION
 
 git checkout -b $RELEASE $devBranch
 
-# generate next version and changelog
-./asdf generate
- 
-# commit version number increment
-git commit -am "version bump to $NEXT_VERSION"
+# generate next version and changelog and commit them
+./asdf generate --commit --commit-message "version bump to {VERSION}"
 
 # merge release into master
 git checkout $MASTER
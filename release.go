@@ -0,0 +1,306 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/Masterminds/semver"
+	"github.com/moolen/asdf/changelog"
+	"github.com/moolen/asdf/repository"
+	"github.com/urfave/cli"
+)
+
+const (
+	// sourceFile uses the latest change of the version file as last release
+	sourceFile = "file"
+	// sourceTag uses the highest semver tag as last release
+	sourceTag = "tag"
+)
+
+// initialVersion is used as latest version if there is no release tag yet
+const initialVersion = "0.0.0"
+
+// defaultTagPrefix is the prefix of the release tags
+// of the tag source if no prefix is configured
+const defaultTagPrefix = "v"
+
+// defaultCommitMessage is the message of the release commit
+const defaultCommitMessage = "chore(release): {VERSION}"
+
+// defaultPackageCommitMessage is the message of the release commit
+// of a package if the default commit message is configured
+const defaultPackageCommitMessage = "chore(release): {PACKAGE} {VERSION}"
+
+var errNoVersionFile = errors.New("version file does not exist, please create one")
+var errReleaseAsNotGreater = errors.New("release-as version must be greater than the latest release")
+
+// latestRelease returns the version of the latest release and the revision
+// it was made at. Depending on the configured source this is either the
+// highest semver tag reachable from HEAD or the latest change of the version file,
+// along with the version the file contains at that commit.
+// If there is no release tag yet, the initialVersion and an empty revision are returned
+func latestRelease(repo *repository.GitRepository, cwd string, cfg *Config) (*semver.Version, string, error) {
+	if cfg.Source == sourceTag {
+		tag, err := repo.LatestVersionTag(cfg.TagPrefix)
+		if err == repository.ErrNoTag {
+			log.Warnf("no release tag with prefix %q found, starting at %s", cfg.TagPrefix, initialVersion)
+			return semver.MustParse(initialVersion), "", nil
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		log.Infof("latest release tag: %s (%s)", tag.Name, tag.Hash)
+		return tag.Version, tag.Name, nil
+	}
+	_, err := os.Stat(path.Join(cwd, cfg.VersionFile))
+	if os.IsNotExist(err) {
+		return nil, "", errNoVersionFile
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	commit, err := repo.LatestChangeOfFile(cfg.VersionFile)
+	if err != nil {
+		return nil, "", err
+	}
+	log.Infof("latest release commit: (%s) %s", commit.Hash, commit.Subject)
+	// the version file may already contain an uncommitted release,
+	// the version of the release commit matches its history
+	content, err := repo.FileAtRevision(commit.Hash, cfg.VersionFile)
+	if err != nil {
+		return nil, "", err
+	}
+	version, err := readVersion(bytes.NewReader(content))
+	if err != nil {
+		return nil, "", err
+	}
+	log.Infof("found version: %s", version)
+	return version, commit.Hash, nil
+}
+
+// promoting is true if the latest release is a prerelease
+// and the next release is a final one
+func promoting(latest *semver.Version, cfg *Config) bool {
+	return latest.Prerelease() != "" && cfg.Prerelease == ""
+}
+
+// historyStart returns the revision the history of the next release starts at.
+// Promoting a prerelease releases all commits since the latest final release,
+// or the whole history if there is none
+func historyStart(repo *repository.GitRepository, cfg *Config, latest *semver.Version, revision string) (string, error) {
+	if !promoting(latest, cfg) {
+		return revision, nil
+	}
+	releases, err := historicReleases(repo, cfg)
+	if err != nil {
+		return "", err
+	}
+	for i := len(releases) - 1; i >= 0; i-- {
+		if releases[i].version.Prerelease() == "" {
+			log.Infof("promoting %s, latest final release: %s", latest, releases[i].ref)
+			return releases[i].ref, nil
+		}
+	}
+	return "", nil
+}
+
+// checkReleasable returns an error if the commits do not lead to a release.
+// Promoting a prerelease, releasing an explicit version or
+// updated dependencies do not require any commits
+func checkReleasable(latest *semver.Version, commits repository.Commits, cfg *Config) error {
+	if promoting(latest, cfg) || cfg.ReleaseAs != "" || len(cfg.updated) > 0 {
+		return nil
+	}
+	if len(commits) == 0 {
+		return errNoCommits
+	}
+	if commits.MaxChange() == repository.NoChange {
+		return errNoRelease
+	}
+	return nil
+}
+
+// releaseChange returns the change of the commits.
+// Updated dependencies lead to at least a patch release
+func releaseChange(commits repository.Commits, cfg *Config) repository.Change {
+	change := commits.MaxChange()
+	if len(cfg.updated) > 0 && change < repository.PatchChange {
+		return repository.PatchChange
+	}
+	return change
+}
+
+// nextVersion computes the version of the next release.
+// If a prerelease pattern is configured, the prerelease number
+// continues from the existing versions with the same pattern.
+// If a build pattern is configured, the build metadata is appended.
+// An explicit release-as version is used as it is
+func nextVersion(repo *repository.GitRepository, cfg *Config, latest *semver.Version, change repository.Change) (*semver.Version, error) {
+	if cfg.ReleaseAs != "" {
+		return releaseAsVersion(latest, cfg.ReleaseAs)
+	}
+	if cfg.InitialDevelopment {
+		change = initialDevelopmentChange(latest, change)
+	}
+	next := nextFinalVersion(latest, change)
+	if cfg.Prerelease == "" && cfg.Build == "" {
+		return &next, nil
+	}
+	head, err := repo.RevParse("HEAD")
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Prerelease != "" {
+		existing := []*semver.Version{latest}
+		tags, err := repo.VersionTags(cfg.TagPrefix)
+		if err != nil {
+			return nil, err
+		}
+		for _, tag := range tags {
+			existing = append(existing, tag.Version)
+		}
+		next, err = prereleaseVersion(next, cfg.Prerelease, changelog.TrimSHA(head), existing)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Build != "" {
+		metadata, err := buildMetadata(cfg.Build, changelog.TrimSHA(head), time.Now())
+		if err != nil {
+			return nil, err
+		}
+		next, err = next.SetMetadata(metadata)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &next, nil
+}
+
+// releaseAsVersion parses the explicit version of the next release
+// which must be greater than the latest release
+func releaseAsVersion(latest *semver.Version, releaseAs string) (*semver.Version, error) {
+	version, err := semver.NewVersion(releaseAs)
+	if err != nil {
+		return nil, err
+	}
+	if !version.GreaterThan(latest) {
+		return nil, errReleaseAsNotGreater
+	}
+	log.Infof("releasing as %s", version)
+	return version, nil
+}
+
+// historySince returns the commits from HEAD to the given revision
+// or the whole history if the revision is empty.
+// Commits that are reverted within the history are left out
+func historySince(repo *repository.GitRepository, revision string) (repository.Commits, error) {
+	var commits repository.Commits
+	var err error
+	if revision == "" {
+		commits, err = repo.GetHistory("HEAD")
+	} else {
+		commits, err = repo.GetHistoryUntil(revision)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return commits.WithoutReverted(), nil
+}
+
+// publishRelease commits the changelog and version file and tags the new
+// version, depending on the configuration. The tag message is the changelog
+func publishRelease(repo *repository.GitRepository, cfg *Config, version *semver.Version, changelog string) error {
+	if cfg.Release.Commit {
+		files := []string{cfg.ChangelogFile}
+		if cfg.Source != sourceTag {
+			files = append(files, cfg.VersionFile)
+		}
+		err := repo.Add(files...)
+		if err != nil {
+			return err
+		}
+		message := cfg.Release.CommitMessage
+		if cfg.packageName() != "" && message == defaultCommitMessage {
+			message = defaultPackageCommitMessage
+		}
+		message = strings.Replace(message, VersionToken, version.String(), -1)
+		message = strings.Replace(message, PackageToken, cfg.packageName(), -1)
+		hash, err := repo.Commit(message)
+		if err != nil {
+			return err
+		}
+		log.Infof("created release commit %s", hash)
+	}
+	if cfg.Release.Tag {
+		name := cfg.TagPrefix + version.String()
+		err := repo.CreateTag(name, changelog, cfg.Release.Sign)
+		if err != nil {
+			return err
+		}
+		log.Infof("created tag %s", name)
+	}
+	return nil
+}
+
+// releaseFlags are shared by all commands that need to find the latest release
+func releaseFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  flagSource,
+			Value: sourceFile,
+			Usage: "where to find the latest release: \"" + sourceFile + "\" uses the latest change of the version file, \"" + sourceTag + "\" the highest semver tag",
+		},
+		cli.StringFlag{
+			Name:  flagTagPrefix,
+			Value: "",
+			Usage: "prefix of the release tags, e.g. \"v\" or \"mylib/v\". Defaults to \"v\" for the " + sourceTag + " source",
+		},
+		cli.StringFlag{
+			Name:  flagHistory,
+			Value: string(repository.NoMerges),
+			Usage: "commits of the history: \"" + string(repository.NoMerges) + "\" all but merge commits, \"" + string(repository.MergesOnly) + "\" the merged pull requests, \"" + string(repository.FirstParent) + "\" the first parent history",
+		},
+		cli.StringFlag{
+			Name:  flagPrerelease,
+			Value: "",
+			Usage: "release a prerelease version, e.g. \"rc." + ReleaseToken + "\" or \"dev." + CommitToken + "\". " + ReleaseToken + " counts up from the existing prereleases, " + CommitToken + " is the short hash of HEAD",
+		},
+		cli.StringFlag{
+			Name:  flagBuild,
+			Value: "",
+			Usage: "append build metadata to the version, e.g. \"sha." + CommitToken + "\" or \"build.{ENV:BUILD_NUMBER}." + DateToken + "\". " + DateToken + " is the current date as YYYYMMDD, " + EnvToken + " the value of an environment variable",
+		},
+		cli.StringFlag{
+			Name:  flagPackage,
+			Value: "",
+			Usage: "release a single package of a monorepo, either by the name of a configured package or its path. By default all configured packages are released",
+		},
+		cli.BoolFlag{
+			Name:  flagInitialDev,
+			Usage: "while the major version is 0, breaking changes bump the minor and features the patch version",
+		},
+		cli.StringFlag{
+			Name:  flagReleaseAs,
+			Value: "",
+			Usage: "release the given version instead of the calculated one, e.g. \"1.0.0\" to leave the initial development",
+		},
+	}
+}
+
+// selectFlags returns the flags with the given names
+func selectFlags(flags []cli.Flag, names ...string) []cli.Flag {
+	var selected []cli.Flag
+	for _, flag := range flags {
+		if contains(names, flag.GetName()) {
+			selected = append(selected, flag)
+		}
+	}
+	return selected
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// Backend reads and writes the git repository.
+// GitRepository delegates all git operations to its Backend
+type Backend interface {
+	// Log returns the commits selected by the options, the latest commit comes first
+	Log(opts LogOptions) ([]RawCommit, error)
+	// Show returns the content of the file at the revision.
+	// The filename is relative to the path of the repository
+	Show(revision, filename string) ([]byte, error)
+	// RevParse returns the commit hash of the revision
+	RevParse(revision string) (string, error)
+	// MergedTags returns the tags reachable from HEAD
+	MergedTags() ([]RawTag, error)
+	// RemoteURL returns the URL of the remote with the given name
+	RemoteURL(name string) (string, error)
+	// GitPath returns the path of a file in the git directory
+	GitPath(name string) (string, error)
+	// Fetch fetches all remotes
+	Fetch() error
+	// Add stages the given files
+	Add(files ...string) error
+	// Commit commits the staged changes
+	Commit(message string) error
+	// CreateTag creates an annotated tag at HEAD
+	CreateTag(name, message string, sign bool) error
+}
+
+// LogOptions select the commits returned by Backend.Log
+type LogOptions struct {
+	// Revisions as understood by git log, e.g. `HEAD` or `v1.0.0..HEAD`
+	Revisions string
+	// Mode selects the commits, see HistoryMode
+	Mode HistoryMode
+	// Paths restricts the commits to the ones that touch one of the paths
+	Paths []string
+	// Max limits the number of commits, zero means no limit
+	Max int
+}
+
+// RawTag is a tag along with the hash of the commit it points to
+type RawTag struct {
+	Name string
+	Hash string
+}
+
+// BackendKind selects the implementation of the Backend
+type BackendKind string
+
+const (
+	// AutoBackend uses the ExecBackend if a git binary is installed,
+	// the NativeBackend otherwise (default)
+	AutoBackend BackendKind = "auto"
+	// ExecBackendKind executes the git binary, see ExecBackend
+	ExecBackendKind BackendKind = "exec"
+	// NativeBackendKind reads the object database directly, see NativeBackend
+	NativeBackendKind BackendKind = "native"
+)
+
+// ErrUnknownBackend is returned if a string does not represent a BackendKind
+var ErrUnknownBackend = errors.New("unknown git backend: expected auto, exec or native")
+
+// ParseBackendKind returns the BackendKind represented by the given string.
+// An empty string is AutoBackend
+func ParseBackendKind(s string) (BackendKind, error) {
+	switch kind := BackendKind(strings.ToLower(s)); kind {
+	case "":
+		return AutoBackend, nil
+	case AutoBackend, ExecBackendKind, NativeBackendKind:
+		return kind, nil
+	}
+	return AutoBackend, ErrUnknownBackend
+}
+
+// NewBackend creates the Backend of the given kind for the repository at repoPath
+func NewBackend(kind BackendKind, repoPath string) Backend {
+	if kind == AutoBackend {
+		kind = ExecBackendKind
+		if _, err := exec.LookPath("git"); err != nil {
+			kind = NativeBackendKind
+		}
+	}
+	if kind == NativeBackendKind {
+		return &NativeBackend{Path: repoPath}
+	}
+	return &ExecBackend{Path: repoPath}
+}
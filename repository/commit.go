@@ -10,7 +10,7 @@ import (
 	"strings"
 	"time"
 
-	log "github.com/Sirupsen/logrus"
+	log "github.com/sirupsen/logrus"
 )
 
 // Change is a custom type indicating a minor/major/patch change
@@ -18,11 +18,13 @@ import (
 type Change int
 
 const (
-	// PatchChange is a patch change (0)
-	PatchChange Change = iota
-	// MinorChange is a minor change (1)
+	// NoChange does not require a release (0)
+	NoChange Change = iota
+	// PatchChange is a patch change (1)
+	PatchChange
+	// MinorChange is a minor change (2)
 	MinorChange
-	// MajorChange is a major change (2)
+	// MajorChange is a major change (3)
 	MajorChange
 )
 
@@ -38,6 +40,12 @@ type Commit struct {
 	Subject      string
 	Body         string
 	Change       Change
+	// Breaking indicates a breaking change, see Message.Breaking
+	Breaking            bool
+	BreakingDescription string
+	Footers             []Footer
+	// PullRequest is the number of the merged pull request, see unwrapMerge
+	PullRequest int
 }
 
 // CommitAuthor holds information regarding the author of the commit
@@ -50,17 +58,19 @@ type CommitAuthor struct {
 // that provides convenient functionality
 type Commits []*Commit
 
-var commitPattern = regexp.MustCompile("^(\\w*)(?:\\((.*)\\))?\\: (.*)$")
-
 // ErrParse happens, if git log gives us wrong output
 var ErrParse = errors.New("could not parse log output")
 
-// CommitMapFunc is a function that receives a commit message,
-// parses it, and returns:
-// - the type of change (feat/ix/breaking)
+// ErrUnknownChange is returned if a string does not represent a Change
+var ErrUnknownChange = errors.New("unknown change: expected major, minor, patch or none")
+
+// CommitMapFunc is a function that receives the subject and body
+// of a commit message, parses it, and returns a Message containing:
+// - the type of change (feat/fix/breaking)
 // - the scope
-// - the stripped message
-type CommitMapFunc func(msg string) (commitType string, scope string, subject string)
+// - the stripped subject
+// - the breaking change and footers
+type CommitMapFunc func(subject, body string) Message
 
 // used as delimiter to split the values from git log
 var delimiter = "~Ü>8~#Ä~8<Ü~"
@@ -80,25 +90,30 @@ var bodyEndSeperator = "((((((((^^^^))))))))"
 
 var logFormatter = strings.Join(formatString, delimiter) + "%n" + bodyBeginSeperator + "%n%b%n" + bodyEndSeperator
 
-// DefaultMapFunc parses the commit message
-// and returns a type
-func DefaultMapFunc(msg string) (commitType string, commitScope string, commitMessage string) {
-	lines := strings.Split(msg, "\n")
-	found := commitPattern.FindAllStringSubmatch(lines[0], -1)
-	if len(found) < 1 {
-		return "", "", msg
-	}
-	commitType = strings.ToLower(found[0][1])
-	commitScope = strings.ToUpper(found[0][2])
-	commitMessage = fmt.Sprintf("%.50s", strings.ToLower(found[0][3]))
-	return
+// RawCommit is a commit as stored by git, before its message is parsed
+type RawCommit struct {
+	Hash         string
+	ParentHashes []string
+	Date         time.Time
+	Author       CommitAuthor
+	Subject      string
+	Body         string
 }
 
 // ParseCommits parses a commit message from an io.Reader
-// and returns a Commit
-func ParseCommits(stdout io.Reader, mapFunc CommitMapFunc) ([]*Commit, error) {
-	var commits []*Commit
-	change := PatchChange
+// and returns a Commit. The changeFunc is called for every commit
+// to determine its Change
+func ParseCommits(stdout io.Reader, mapFunc CommitMapFunc, changeFunc ChangeFunc) ([]*Commit, error) {
+	raws, err := parseLog(stdout)
+	if err != nil {
+		return nil, err
+	}
+	return mapCommits(raws, mapFunc, changeFunc), nil
+}
+
+// parseLog parses the output of git log formatted with the logFormatter
+func parseLog(stdout io.Reader) ([]RawCommit, error) {
+	var commits []RawCommit
 	scanner := bufio.NewScanner(stdout)
 	for scanner.Scan() {
 		// first line is always the commit metadata
@@ -127,32 +142,48 @@ func ParseCommits(stdout io.Reader, mapFunc CommitMapFunc) ([]*Commit, error) {
 		if err != nil {
 			return nil, err
 		}
-		changedDate := time.Unix(unixSeconds, 0)
-		commitType, commitScope, commitMessage := mapFunc(parsedMetadata[5])
-		if commitType == "feat" {
-			change = MinorChange
-		}
-		if strings.HasPrefix(body, "BREAKING CHANGE") {
-			change = MajorChange
-		}
-		commits = append(commits, &Commit{
-			ParentHashes: parsedMetadata[0],
+		commits = append(commits, RawCommit{
+			ParentHashes: strings.Fields(parsedMetadata[0]),
 			Hash:         parsedMetadata[1],
-			Date:         changedDate,
-			Subject:      commitMessage,
-			Body:         body,
+			Date:         time.Unix(unixSeconds, 0),
 			Author: CommitAuthor{
 				Name:  parsedMetadata[3],
 				Email: parsedMetadata[4],
 			},
-			Change: change,
-			Scope:  commitScope,
-			Type:   commitType,
+			Subject: parsedMetadata[5],
+			Body:    body,
 		})
 	}
 	return commits, nil
 }
 
+// mapCommits parses the messages of the raw commits with the mapFunc
+// and determines their Change with the changeFunc
+func mapCommits(raws []RawCommit, mapFunc CommitMapFunc, changeFunc ChangeFunc) []*Commit {
+	var commits []*Commit
+	for _, raw := range raws {
+		subject, body, pullRequest := unwrapMerge(raw.Subject, raw.Body, len(raw.ParentHashes) > 1)
+		msg := mapFunc(subject, body)
+		commit := &Commit{
+			ParentHashes:        strings.Join(raw.ParentHashes, " "),
+			Hash:                raw.Hash,
+			Date:                raw.Date,
+			Subject:             msg.Subject,
+			Body:                body,
+			Author:              raw.Author,
+			Scope:               msg.Scope,
+			Type:                msg.Type,
+			Breaking:            msg.Breaking,
+			BreakingDescription: msg.BreakingDescription,
+			Footers:             msg.Footers,
+			PullRequest:         pullRequest,
+		}
+		commit.Change = changeFunc(commit)
+		commits = append(commits, commit)
+	}
+	return commits
+}
+
 // Change should be a Stringer
 func (c Change) String() string {
 	switch c {
@@ -160,13 +191,59 @@ func (c Change) String() string {
 		return "major"
 	case MinorChange:
 		return "minor"
+	case PatchChange:
+		return "patch"
+	}
+	return "none"
+}
+
+// ParseChange returns the Change represented by the given string.
+// It is the inverse of Change.String()
+func ParseChange(s string) (Change, error) {
+	switch strings.ToLower(s) {
+	case "major":
+		return MajorChange, nil
+	case "minor":
+		return MinorChange, nil
+	case "patch":
+		return PatchChange, nil
+	case "none":
+		return NoChange, nil
+	}
+	return NoChange, ErrUnknownChange
+}
+
+// Impact describes the aggregate Change of a list of commits
+// and the commits that cause it
+type Impact struct {
+	Change  Change
+	Commits Commits
+}
+
+// Impact returns the aggregate Change of the commits
+// along with the commits that cause it
+func (commits Commits) Impact() Impact {
+	max := commits.MaxChange()
+	return Impact{
+		Change:  max,
+		Commits: commits.ByChange(max),
+	}
+}
+
+// ByChange returns the commits that cause the given Change
+func (commits Commits) ByChange(change Change) Commits {
+	var result Commits
+	for _, commit := range commits {
+		if commit.Change == change {
+			result = append(result, commit)
+		}
 	}
-	return "patch"
+	return result
 }
 
 // MaxChange gives us the max
 func (commits Commits) MaxChange() Change {
-	max := PatchChange
+	max := NoChange
 	for _, commit := range commits {
 		if max < commit.Change {
 			max = commit.Change
@@ -174,3 +251,61 @@ func (commits Commits) MaxChange() Change {
 	}
 	return max
 }
+
+// revertPattern matches the line git adds to the body of a revert
+var revertPattern = regexp.MustCompile(`(?m)^This reverts commit ([0-9a-f]{7,40})`)
+
+// RevertedHash returns the hash of the commit this commit reverts
+// according to its body, or an empty string if it is no revert
+func (c *Commit) RevertedHash() string {
+	found := revertPattern.FindStringSubmatch(c.Body)
+	if found == nil {
+		return ""
+	}
+	return found[1]
+}
+
+// WithoutReverted removes the commits that are reverted within the commits
+// along with their reverts, so neither counts for the next version nor is
+// listed in the changelog. Reverts of commits that are not part of the
+// commits, e.g. of a previous release, are kept. A commit is in effect
+// unless a revert that is in effect reverts it, so reverting a revert
+// restores the originally reverted commit. The commits are expected
+// in the order of git log, the latest commit comes first
+func (commits Commits) WithoutReverted() Commits {
+	reverted := make(map[*Commit]bool)
+	// internal are the reverts of commits within the commits
+	internal := make(map[*Commit]bool)
+	// a revert only reverts older commits, so whether a commit
+	// is reverted is known before the commit it reverts is reached
+	for i, commit := range commits {
+		target := commits[i+1:].find(commit.RevertedHash())
+		if target == nil {
+			continue
+		}
+		internal[commit] = true
+		if !reverted[commit] {
+			reverted[target] = true
+		}
+	}
+	var result Commits
+	for _, commit := range commits {
+		if !reverted[commit] && !internal[commit] {
+			result = append(result, commit)
+		}
+	}
+	return result
+}
+
+// find returns the commit with the given, possibly abbreviated, hash
+func (commits Commits) find(hash string) *Commit {
+	if hash == "" {
+		return nil
+	}
+	for _, commit := range commits {
+		if strings.HasPrefix(commit.Hash, hash) {
+			return commit
+		}
+	}
+	return nil
+}
@@ -61,12 +61,17 @@ func TestCommitParser(t *testing.T) {
 						Name:  "Moritz Johner",
 						Email: "beller.moritz@googlemail.com",
 					},
-					Date:    time.Unix(1510488640, 0),
-					Type:    "docs",
-					Scope:   "MYSCOPE",
-					Subject: "docs changed something 2",
-					Body:    "BREAKING CHANGE: my mom puked!\nBAZLER\n",
-					Change:  MajorChange,
+					Date:                time.Unix(1510488640, 0),
+					Type:                "docs",
+					Scope:               "MYSCOPE",
+					Subject:             "docs changed something 2",
+					Body:                "BREAKING CHANGE: my mom puked!\nBAZLER\n",
+					Change:              MajorChange,
+					Breaking:            true,
+					BreakingDescription: "my mom puked!\nBAZLER",
+					Footers: []Footer{
+						{Token: "BREAKING CHANGE", Value: "my mom puked!\nBAZLER"},
+					},
 				},
 			},
 		},
@@ -79,7 +84,7 @@ func TestCommitParser(t *testing.T) {
 	}
 
 	for i, row := range table {
-		commits, err := ParseCommits(strings.NewReader(row.in), DefaultMapFunc)
+		commits, err := ParseCommits(strings.NewReader(row.in), DefaultMapFunc, DefaultChangeFunc)
 		if !reflect.DeepEqual(row.err, err) {
 			numErr, ok := err.(*strconv.NumError)
 			if !ok {
@@ -95,55 +100,121 @@ func TestCommitParser(t *testing.T) {
 	}
 }
 
-func TestDefaultMapFunc(t *testing.T) {
+func TestParseChange(t *testing.T) {
 	table := []struct {
-		in      string
-		tp      string
-		scope   string
-		subject string
+		in     string
+		change Change
+		err    error
+	}{
+		{in: "major", change: MajorChange},
+		{in: "Minor", change: MinorChange},
+		{in: "patch", change: PatchChange},
+		{in: "none", change: NoChange},
+		{in: "huge", change: NoChange, err: ErrUnknownChange},
+	}
+	for i, row := range table {
+		change, err := ParseChange(row.in)
+		if err != row.err {
+			t.Fatalf("[%d] expected err %v, got %v", i, row.err, err)
+		}
+		if change != row.change {
+			t.Fatalf("[%d] expected %s, got %s", i, row.change, change)
+		}
+	}
+}
+
+func TestParseCommitsChangePerCommit(t *testing.T) {
+	// git log lists the newest commit first: the breaking change
+	// and the feature must not leak into the older commits
+	lines := []string{
+		"p1~Ü>8~#Ä~8<Ü~h1~Ü>8~#Ä~8<Ü~1510488640~Ü>8~#Ä~8<Ü~a~Ü>8~#Ä~8<Ü~a@b~Ü>8~#Ä~8<Ü~fix: newest\n((((((((----))))))))\nBREAKING CHANGE: gone\n((((((((^^^^))))))))",
+		"p2~Ü>8~#Ä~8<Ü~h2~Ü>8~#Ä~8<Ü~1510488640~Ü>8~#Ä~8<Ü~a~Ü>8~#Ä~8<Ü~a@b~Ü>8~#Ä~8<Ü~feat: feature\n((((((((----))))))))\n\n((((((((^^^^))))))))",
+		"p3~Ü>8~#Ä~8<Ü~h3~Ü>8~#Ä~8<Ü~1510488640~Ü>8~#Ä~8<Ü~a~Ü>8~#Ä~8<Ü~a@b~Ü>8~#Ä~8<Ü~fix: oldest\n((((((((----))))))))\n\n((((((((^^^^))))))))",
+	}
+	commits, err := ParseCommits(strings.NewReader(strings.Join(lines, "\n")), DefaultMapFunc, DefaultChangeFunc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Change{MajorChange, MinorChange, PatchChange}
+	for i, commit := range commits {
+		if commit.Change != expected[i] {
+			t.Fatalf("[%d] expected %s, got %s", i, expected[i], commit.Change)
+		}
+	}
+}
+
+func TestImpact(t *testing.T) {
+	feat1 := &Commit{Hash: "1", Change: MinorChange}
+	feat2 := &Commit{Hash: "2", Change: MinorChange}
+	commits := Commits{
+		{Hash: "0", Change: PatchChange},
+		feat1,
+		{Hash: "3", Change: NoChange},
+		feat2,
+	}
+	impact := commits.Impact()
+	if impact.Change != MinorChange {
+		t.Fatalf("expected minor, got %s", impact.Change)
+	}
+	if !reflect.DeepEqual(impact.Commits, Commits{feat1, feat2}) {
+		t.Fatalf("unexpected commits: %#v", impact.Commits)
+	}
+	impact = Commits{}.Impact()
+	if impact.Change != NoChange || len(impact.Commits) != 0 {
+		t.Fatalf("unexpected impact: %#v", impact)
+	}
+}
+
+func TestWithoutReverted(t *testing.T) {
+	reverts := func(hash string) string {
+		return "This reverts commit " + hash + ".\n"
+	}
+	feat := &Commit{Hash: "aaaaaaaa11"}
+	fix := &Commit{Hash: "bbbbbbbb22"}
+	revertFeat := &Commit{Hash: "cccccccc33", Body: reverts(feat.Hash)}
+	revertReleased := &Commit{Hash: "dddddddd44", Body: reverts("eeeeeeee55")}
+	revertRevert := &Commit{Hash: "ffffffff66", Body: reverts("cccccccc")}
+	revertRevertRevert := &Commit{Hash: "abababab77", Body: reverts(revertRevert.Hash)}
+	table := []struct {
+		commits  Commits
+		expected Commits
 	}{
 		{
-			in:      "feat(TICKK-123): foobar booman",
-			tp:      "feat",
-			scope:   "TICKK-123",
-			subject: "foobar booman",
+			commits:  Commits{revertFeat, fix, feat},
+			expected: Commits{fix},
 		},
 		{
-			in:      "fart(): foobar booman",
-			tp:      "fart",
-			subject: "foobar booman",
+			// the reverted commit is not part of the commits
+			commits:  Commits{revertReleased, fix},
+			expected: Commits{revertReleased, fix},
 		},
 		{
-			in:      "(TICKK-123): foobar booman",
-			scope:   "TICKK-123",
-			subject: "foobar booman",
+			// reverting the revert restores the commit
+			commits:  Commits{revertRevert, revertFeat, feat},
+			expected: Commits{feat},
 		},
 		{
-			in:      "fang: foobar booman",
-			tp:      "fang",
-			subject: "foobar booman",
+			// reverting it a third time reverts the commit again
+			commits:  Commits{revertRevertRevert, revertRevert, revertFeat, fix, feat},
+			expected: Commits{fix},
 		},
 		{
-			in:      "fang foobar booman",
-			subject: "fang foobar booman",
+			// a revert of a released commit and its revert cancel out
+			commits:  Commits{revertRevertRevert, revertRevert, fix},
+			expected: Commits{fix},
 		},
 		{
-			in:      "feat(1): silly fix we have a maximum line length here. everything >50 chars should be redacted",
-			tp:      "feat",
-			scope:   "1",
-			subject: "silly fix we have a maximum line length here. ever",
+			// a revert older than the commit does not revert it
+			commits:  Commits{feat, revertFeat},
+			expected: Commits{feat, revertFeat},
+		},
+		{
+			commits: Commits{},
 		},
 	}
 	for i, row := range table {
-		tp, scope, msg := DefaultMapFunc(row.in)
-		if tp != row.tp {
-			t.Fatalf("[%d] wrong type: expected %#v, got %#v", i, row.tp, tp)
-		}
-		if scope != row.scope {
-			t.Fatalf("[%d] wrong type: expected %#v, got %#v", i, row.scope, scope)
-		}
-		if msg != row.subject {
-			t.Fatalf("[%d] wrong type: expected %#v, got %#v", i, row.subject, msg)
+		if result := row.commits.WithoutReverted(); !reflect.DeepEqual(result, row.expected) {
+			t.Fatalf("[%d] expected %#v, got %#v", i, row.expected, result)
 		}
 	}
 }
@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ExecBackend executes the git binary in the Path of the repository
+// and parses its output
+type ExecBackend struct {
+	Path string
+}
+
+// the commit of annotated tags is the dereferenced object
+var tagFormat = "%(refname)%09%(objectname)%09%(*objectname)"
+
+// Log runs git log
+func (b *ExecBackend) Log(opts LogOptions) ([]RawCommit, error) {
+	args := []string{"log", "--format=" + logFormatter}
+	if arg := opts.Mode.logArg(); arg != "" {
+		args = append(args, arg)
+	}
+	if opts.Max > 0 {
+		args = append(args, "-n"+strconv.Itoa(opts.Max))
+	}
+	if opts.Revisions != "" {
+		args = append(args, opts.Revisions)
+	}
+	if len(opts.Paths) > 0 {
+		args = append(append(args, "--"), opts.Paths...)
+	}
+	out, _, err := execDir(b.Path, "git", args...)
+	if err != nil {
+		return nil, err
+	}
+	return parseLog(out)
+}
+
+// Show runs git show
+func (b *ExecBackend) Show(revision, filename string) ([]byte, error) {
+	out, _, err := execDir(b.Path, "git", "show", revision+":./"+filename)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(out)
+}
+
+// RevParse runs git rev-parse
+func (b *ExecBackend) RevParse(revision string) (string, error) {
+	return execLine(b.Path, "git", "rev-parse", "--verify", revision+"^{commit}")
+}
+
+// MergedTags runs git for-each-ref
+func (b *ExecBackend) MergedTags() ([]RawTag, error) {
+	out, _, err := execDir(b.Path, "git", "for-each-ref", "--merged", "HEAD", "--format="+tagFormat, "refs/tags/")
+	if err != nil {
+		return nil, err
+	}
+	var tags []RawTag
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) != 3 {
+			return nil, ErrParse
+		}
+		hash := fields[1]
+		if fields[2] != "" {
+			hash = fields[2]
+		}
+		tags = append(tags, RawTag{
+			Name: strings.TrimPrefix(fields[0], "refs/tags/"),
+			Hash: hash,
+		})
+	}
+	return tags, nil
+}
+
+// RemoteURL runs git remote get-url
+func (b *ExecBackend) RemoteURL(name string) (string, error) {
+	return execLine(b.Path, "git", "remote", "get-url", name)
+}
+
+// GitPath runs git rev-parse --git-path.
+// Worktrees and configured paths like core.hooksPath are respected
+func (b *ExecBackend) GitPath(name string) (string, error) {
+	gitPath, err := execLine(b.Path, "git", "rev-parse", "--git-path", name)
+	if err != nil {
+		return "", err
+	}
+	if filepath.IsAbs(gitPath) {
+		return gitPath, nil
+	}
+	return filepath.Join(b.Path, gitPath), nil
+}
+
+// Fetch runs git fetch --all
+func (b *ExecBackend) Fetch() error {
+	_, _, err := execDir(b.Path, "git", "fetch", "--all")
+	return err
+}
+
+// Add runs git add
+func (b *ExecBackend) Add(files ...string) error {
+	_, _, err := execDir(b.Path, "git", append([]string{"add", "--"}, files...)...)
+	return err
+}
+
+// Commit runs git commit
+func (b *ExecBackend) Commit(message string) error {
+	_, _, err := execDir(b.Path, "git", "commit", "--cleanup=whitespace", "-m", message)
+	return err
+}
+
+// CreateTag runs git tag, the tag is signed with the default GPG key if sign is true
+func (b *ExecBackend) CreateTag(name, message string, sign bool) error {
+	mode := "-a"
+	if sign {
+		mode = "-s"
+	}
+	_, _, err := execDir(b.Path, "git", "tag", mode, "--cleanup=whitespace", name, "-m", message)
+	return err
+}
+
+// execLine executes a command and returns its trimmed output
+func execLine(dir, cmd string, things ...string) (string, error) {
+	out, _, err := execDir(dir, cmd, things...)
+	if err != nil {
+		return "", err
+	}
+	line, err := ioutil.ReadAll(out)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(line)), nil
+}
+
+// ExecError is returned if a git command fails.
+// It contains the command and its error output
+// and matches ErrExec, see errors.Is
+type ExecError struct {
+	Command string
+	Stderr  string
+	Err     error
+}
+
+func (e *ExecError) Error() string {
+	msg := fmt.Sprintf("%v: %s: %v", ErrExec, e.Command, e.Err)
+	if e.Stderr != "" {
+		msg += ": " + e.Stderr
+	}
+	return msg
+}
+
+// Is reports whether the target is ErrExec
+func (e *ExecError) Is(target error) bool {
+	return target == ErrExec
+}
+
+// Unwrap returns the error of the command
+func (e *ExecError) Unwrap() error {
+	return e.Err
+}
+
+// execDir executes a command in a specific directory.
+// A failed command returns an ExecError
+func execDir(dir, cmd string, things ...string) (io.Reader, io.Reader, error) {
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	c := exec.Command(cmd, things...)
+	c.Dir = dir
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+	err := c.Run()
+	if err != nil {
+		return nil, nil, &ExecError{
+			Command: strings.Join(append([]string{cmd}, things...), " "),
+			Stderr:  strings.TrimSpace(stderr.String()),
+			Err:     err,
+		}
+	}
+	return &stdout, &stderr, nil
+}
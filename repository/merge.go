@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// HistoryMode selects the commits of the history
+type HistoryMode string
+
+const (
+	// NoMerges lists all commits except merge commits (default)
+	NoMerges HistoryMode = "no-merges"
+	// MergesOnly lists the merge commits only, e.g. the merged pull requests
+	MergesOnly HistoryMode = "merges-only"
+	// FirstParent follows the first parent of merge commits,
+	// so the commits of merged branches are left out
+	FirstParent HistoryMode = "first-parent"
+	// AllCommits lists merge and regular commits alike.
+	// It is used to look up single commits and is not accepted by ParseHistoryMode
+	AllCommits HistoryMode = "all"
+)
+
+// ErrUnknownHistoryMode is returned if a string does not represent a HistoryMode
+var ErrUnknownHistoryMode = errors.New("unknown history mode: expected no-merges, merges-only or first-parent")
+
+// ParseHistoryMode returns the HistoryMode represented by the given string.
+// An empty string is NoMerges
+func ParseHistoryMode(s string) (HistoryMode, error) {
+	switch mode := HistoryMode(strings.ToLower(s)); mode {
+	case "":
+		return NoMerges, nil
+	case NoMerges, MergesOnly, FirstParent:
+		return mode, nil
+	}
+	return NoMerges, ErrUnknownHistoryMode
+}
+
+// logArg returns the git log argument of the mode, AllCommits has none
+func (m HistoryMode) logArg() string {
+	switch m {
+	case MergesOnly:
+		return "--merges"
+	case FirstParent:
+		return "--first-parent"
+	case AllCommits:
+		return ""
+	}
+	return "--no-merges"
+}
+
+// selects reports whether the mode lists a commit with the given number of parents
+func (m HistoryMode) selects(parents int) bool {
+	switch m {
+	case MergesOnly:
+		return parents > 1
+	case FirstParent, AllCommits:
+		return true
+	}
+	return parents <= 1
+}
+
+// mergeSubjectPatterns match the subjects of merged pull requests
+// created by GitHub and Bitbucket, the first group is the number
+var mergeSubjectPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`^Merge pull request #(\d+) from \S+`),
+	regexp.MustCompile(`^Merged in \S+ \(pull request #(\d+)\)`),
+}
+
+// mergeRequestPattern matches the reference in the body of a merge request merged by GitLab
+var mergeRequestPattern = regexp.MustCompile(`(?m)^See merge request \S*!(\d+)\s*$`)
+
+// squashPattern matches the pull request suffix of squash merged subjects, e.g. `feat: x (#123)`
+var squashPattern = regexp.MustCompile(`\s\(#(\d+)\)$`)
+
+// unwrapMerge returns the message of a merged pull request: the subject of
+// a merge commit is replaced by the title of the pull request, which is the
+// first line of the body. The pull request number is 0 if it is not found
+func unwrapMerge(subject, body string, merge bool) (string, string, int) {
+	if found := squashPattern.FindStringSubmatch(subject); found != nil {
+		number, _ := strconv.Atoi(found[1])
+		return subject, body, number
+	}
+	if !merge {
+		return subject, body, 0
+	}
+	number := 0
+	for _, pattern := range mergeSubjectPatterns {
+		if found := pattern.FindStringSubmatch(subject); found != nil {
+			number, _ = strconv.Atoi(found[1])
+		}
+	}
+	if found := mergeRequestPattern.FindStringSubmatch(body); found != nil {
+		number, _ = strconv.Atoi(found[1])
+		body = mergeRequestPattern.ReplaceAllString(body, "")
+	}
+	title := strings.TrimLeft(body, "\n")
+	if number == 0 || strings.TrimSpace(title) == "" {
+		return subject, body, number
+	}
+	lines := strings.SplitN(title, "\n", 2)
+	subject = strings.TrimSpace(lines[0])
+	body = ""
+	if len(lines) > 1 {
+		body = strings.TrimLeft(lines[1], "\n")
+	}
+	return subject, body, number
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnwrapMerge(t *testing.T) {
+	table := []struct {
+		subject, body string
+		merge         bool
+		expected      []interface{}
+	}{
+		{
+			subject:  "Merge pull request #12 from o/feature",
+			body:     "feat(api): new endpoint\n\nCloses #3\n",
+			merge:    true,
+			expected: []interface{}{"feat(api): new endpoint", "Closes #3\n", 12},
+		},
+		{
+			subject:  "Merged in feature (pull request #7)",
+			body:     "fix: a bug\n",
+			merge:    true,
+			expected: []interface{}{"fix: a bug", "", 7},
+		},
+		{
+			subject:  "Merge branch 'feature' into 'main'",
+			body:     "feat: new endpoint\n\nSee merge request group/project!34\n",
+			merge:    true,
+			expected: []interface{}{"feat: new endpoint", "", 34},
+		},
+		{
+			subject:  "Merge branch 'main' into feature",
+			body:     "",
+			merge:    true,
+			expected: []interface{}{"Merge branch 'main' into feature", "", 0},
+		},
+		{
+			subject:  "Merge pull request #12 from o/feature",
+			body:     "",
+			merge:    true,
+			expected: []interface{}{"Merge pull request #12 from o/feature", "", 12},
+		},
+		{
+			subject:  "feat: squashed (#45)",
+			body:     "* first commit\n",
+			expected: []interface{}{"feat: squashed (#45)", "* first commit\n", 45},
+		},
+		{
+			subject:  "Merge pull request #12 from o/feature",
+			body:     "feat: not a merge commit\n",
+			expected: []interface{}{"Merge pull request #12 from o/feature", "feat: not a merge commit\n", 0},
+		},
+	}
+	for i, row := range table {
+		subject, body, number := unwrapMerge(row.subject, row.body, row.merge)
+		if result := []interface{}{subject, body, number}; !reflect.DeepEqual(result, row.expected) {
+			t.Fatalf("[%d] expected %#v, got %#v", i, row.expected, result)
+		}
+	}
+}
+
+func TestGetHistoryModes(t *testing.T) {
+	repoPath := createRepository()
+	execDir(repoPath, "git", "checkout", "-b", "feature")
+	createAndCommit(repoPath, "wip")
+	execDir(repoPath, "git", "checkout", "master")
+	createAndCommit(repoPath, "fix: direct (#3)")
+	execDir(repoPath, "git", "merge", "--no-ff", "feature", "-m", "Merge pull request #5 from o/feature", "-m", "feat: merged feature")
+	table := []struct {
+		mode     HistoryMode
+		subjects []string
+	}{
+		{mode: "", subjects: []string{"direct (#3)", "wip", "initial commit"}},
+		{mode: MergesOnly, subjects: []string{"merged feature"}},
+		{mode: FirstParent, subjects: []string{"merged feature", "direct (#3)", "initial commit"}},
+	}
+	for i, row := range table {
+		repo := New(repoPath, DefaultMapFunc)
+		repo.Mode = row.mode
+		commits, err := repo.GetHistory("HEAD")
+		if err != nil {
+			t.Fatalf("[%d] error: %v", i, err)
+		}
+		var subjects []string
+		for _, commit := range commits {
+			subjects = append(subjects, commit.Subject)
+		}
+		if !reflect.DeepEqual(subjects, row.subjects) {
+			t.Fatalf("[%d] expected %v, got %v", i, row.subjects, subjects)
+		}
+		if row.mode == MergesOnly && (commits[0].Type != "feat" || commits[0].PullRequest != 5) {
+			t.Fatalf("[%d] unexpected merge commit %#v", i, commits[0])
+		}
+	}
+}
+
+func TestParseHistoryMode(t *testing.T) {
+	for _, s := range []string{"", "no-merges", "Merges-Only", "first-parent"} {
+		if _, err := ParseHistoryMode(s); err != nil {
+			t.Fatalf("%s: unexpected error: %v", s, err)
+		}
+	}
+	if _, err := ParseHistoryMode("all"); err != ErrUnknownHistoryMode {
+		t.Fatalf("expected error, got %v", err)
+	}
+}
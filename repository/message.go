@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Message is a commit message parsed according to
+// the conventional commits specification: http://conventionalcommits.org
+// Subject and Scope are kept as they are, only the Type is lowercased
+type Message struct {
+	Type    string
+	Scope   string
+	Subject string
+	// Breaking is set if the header contains the `!` marker
+	// or a BREAKING CHANGE footer is present
+	Breaking bool
+	// BreakingDescription contains the text of the BREAKING CHANGE footers.
+	// If the breaking change is indicated by the `!` marker only,
+	// it contains the subject
+	BreakingDescription string
+	Footers             []Footer
+}
+
+// Footer is a git trailer style footer of a commit message,
+// e.g. `Reviewed-by: Jane Doe` or `Closes #12`.
+// Footers using the ` #` separator keep the `#` in the value
+type Footer struct {
+	Token string `json:"token" yaml:"token"`
+	Value string `json:"value" yaml:"value"`
+}
+
+var commitPattern = regexp.MustCompile("^(\\w*)(?:\\((.*)\\))?(!)?\\: (.*)$")
+
+// footerPattern matches the first line of a footer. Besides the spec compliant
+// `BREAKING CHANGE` and `BREAKING-CHANGE` tokens `BREAKING CHANGES` is accepted
+var footerPattern = regexp.MustCompile("^(BREAKING[ -]CHANGES?|[\\w-]+)(: | #)(.*)$")
+
+// gitRevertPattern matches the subject git uses for reverts, e.g. `Revert "feat: x"`
+var gitRevertPattern = regexp.MustCompile(`^Revert "(.*)"$`)
+
+// RevertType is the type of reverts
+const RevertType = "revert"
+
+var breakingTokenPattern = regexp.MustCompile("^BREAKING[ -]CHANGES?$")
+
+// DefaultMapFunc parses the commit subject and body
+// according to the conventional commits specification.
+// Reverts created by git are of the RevertType and keep
+// the header of the reverted commit as subject
+func DefaultMapFunc(subject, body string) Message {
+	msg := Message{
+		Subject: subject,
+	}
+	found := commitPattern.FindAllStringSubmatch(subject, -1)
+	if reverted := gitRevertPattern.FindStringSubmatch(subject); reverted != nil {
+		msg.Type = RevertType
+		msg.Subject = reverted[1]
+	} else if len(found) > 0 {
+		msg.Type = strings.ToLower(found[0][1])
+		msg.Scope = found[0][2]
+		msg.Subject = found[0][4]
+		if found[0][3] == "!" {
+			msg.Breaking = true
+			msg.BreakingDescription = found[0][4]
+		}
+	}
+	msg.Footers = ParseFooters(body)
+	var descriptions []string
+	for _, footer := range msg.Footers {
+		if breakingTokenPattern.MatchString(footer.Token) {
+			msg.Breaking = true
+			descriptions = append(descriptions, footer.Value)
+		}
+	}
+	if len(descriptions) > 0 {
+		msg.BreakingDescription = strings.Join(descriptions, "\n\n")
+	}
+	return msg
+}
+
+// ParseFooters returns the footers of a commit body.
+// Like git interpret-trailers, the trailer block is the final paragraph
+// and it has to begin with a footer. A paragraph that begins with a
+// BREAKING CHANGE footer starts the trailer block early, as the description
+// of a breaking change may span paragraphs.
+// Lines that do not start a new footer belong to the value of the previous one
+func ParseFooters(body string) []Footer {
+	lines := strings.Split(strings.TrimSpace(body), "\n")
+	start := 0
+	paragraphStart := true
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			paragraphStart = true
+			continue
+		}
+		if paragraphStart {
+			found := footerPattern.FindStringSubmatch(line)
+			if found != nil && breakingTokenPattern.MatchString(found[1]) {
+				start = i
+				break
+			}
+			start = i
+		}
+		paragraphStart = false
+	}
+	var footers []Footer
+	for _, line := range lines[start:] {
+		found := footerPattern.FindStringSubmatch(line)
+		switch {
+		case found != nil:
+			value := found[3]
+			if found[2] == " #" {
+				value = "#" + value
+			}
+			footers = append(footers, Footer{
+				Token: found[1],
+				Value: value,
+			})
+		case len(footers) > 0:
+			last := &footers[len(footers)-1]
+			last.Value += "\n" + line
+		default:
+			return nil
+		}
+	}
+	for i := range footers {
+		footers[i].Value = strings.TrimSpace(footers[i].Value)
+	}
+	return footers
+}
+
+// String returns the footer as it is written in a commit message
+func (f Footer) String() string {
+	if strings.HasPrefix(f.Value, "#") {
+		return f.Token + " " + f.Value
+	}
+	return f.Token + ": " + f.Value
+}
+
+// Footer returns the value of the first footer with the given token.
+// The token is compared case insensitive
+func (c *Commit) Footer(token string) (string, bool) {
+	for _, footer := range c.Footers {
+		if strings.EqualFold(footer.Token, token) {
+			return footer.Value, true
+		}
+	}
+	return "", false
+}
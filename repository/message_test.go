@@ -0,0 +1,215 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDefaultMapFunc(t *testing.T) {
+	table := []struct {
+		in      string
+		body    string
+		tp      string
+		scope   string
+		subject string
+	}{
+		{
+			in:      "feat(TICKK-123): foobar booman",
+			tp:      "feat",
+			scope:   "TICKK-123",
+			subject: "foobar booman",
+		},
+		{
+			in:      "fart(): foobar booman",
+			tp:      "fart",
+			subject: "foobar booman",
+		},
+		{
+			in:      "(TICKK-123): foobar booman",
+			scope:   "TICKK-123",
+			subject: "foobar booman",
+		},
+		{
+			in:      "fang: foobar booman",
+			tp:      "fang",
+			subject: "foobar booman",
+		},
+		{
+			in:      "fang foobar booman",
+			subject: "fang foobar booman",
+		},
+		{
+			in:      "Revert \"feat(api): new endpoint\"",
+			body:    "This reverts commit 0123456789abcdef0123456789abcdef01234567.",
+			tp:      "revert",
+			subject: "feat(api): new endpoint",
+		},
+		{
+			in:      "feat(1): there is no maximum line length here. everything >50 chars is kept",
+			tp:      "feat",
+			scope:   "1",
+			subject: "there is no maximum line length here. everything >50 chars is kept",
+		},
+		{
+			in:      "feat(api)!: drop v1",
+			tp:      "feat",
+			scope:   "api",
+			subject: "drop v1",
+		},
+		{
+			in:      "Fix(iOS): Support GitHub URLs in JSON",
+			tp:      "fix",
+			scope:   "iOS",
+			subject: "Support GitHub URLs in JSON",
+		},
+	}
+	for i, row := range table {
+		msg := DefaultMapFunc(row.in, row.body)
+		if msg.Type != row.tp {
+			t.Fatalf("[%d] wrong type: expected %#v, got %#v", i, row.tp, msg.Type)
+		}
+		if msg.Scope != row.scope {
+			t.Fatalf("[%d] wrong scope: expected %#v, got %#v", i, row.scope, msg.Scope)
+		}
+		if msg.Subject != row.subject {
+			t.Fatalf("[%d] wrong subject: expected %#v, got %#v", i, row.subject, msg.Subject)
+		}
+	}
+}
+
+func TestDefaultMapFuncBreaking(t *testing.T) {
+	table := []struct {
+		subject     string
+		body        string
+		breaking    bool
+		description string
+	}{
+		{
+			subject: "feat: foo",
+			body:    "some body\n",
+		},
+		{
+			subject:     "feat!: drop support for node 6",
+			breaking:    true,
+			description: "drop support for node 6",
+		},
+		{
+			subject:     "feat(api)!: drop v1",
+			body:        "BREAKING CHANGE: the v1 endpoints are gone\n",
+			breaking:    true,
+			description: "the v1 endpoints are gone",
+		},
+		{
+			subject:     "fix: foo",
+			body:        "some body\n\nRefs: #123\nBREAKING-CHANGE: config moved\n",
+			breaking:    true,
+			description: "config moved",
+		},
+		{
+			subject:     "fix: foo",
+			body:        "BREAKING CHANGES: legacy plural form\n",
+			breaking:    true,
+			description: "legacy plural form",
+		},
+		{
+			// the description of a breaking change may span paragraphs
+			subject:     "feat(api): add thing",
+			body:        "BREAKING CHANGE: removed the v1 API\n\nMigrate by calling v2.\n",
+			breaking:    true,
+			description: "removed the v1 API\n\nMigrate by calling v2.",
+		},
+		{
+			// not a footer: the token must be at the start of the line
+			subject: "fix: foo",
+			body:    "this is no BREAKING CHANGE: really\n",
+		},
+		{
+			subject: "fix: foo",
+			body:    "the body mentions\nBREAKING CHANGE: mid paragraph\n",
+		},
+	}
+	for i, row := range table {
+		msg := DefaultMapFunc(row.subject, row.body)
+		if msg.Breaking != row.breaking {
+			t.Fatalf("[%d] expected breaking %t, got %t", i, row.breaking, msg.Breaking)
+		}
+		if msg.BreakingDescription != row.description {
+			t.Fatalf("[%d] expected description %#v, got %#v", i, row.description, msg.BreakingDescription)
+		}
+	}
+}
+
+func TestParseFooters(t *testing.T) {
+	table := []struct {
+		body    string
+		footers []Footer
+	}{
+		{
+			body: "",
+		},
+		{
+			body: "just a body\nwith two lines\n",
+		},
+		{
+			body: "a body\n\nReviewed-by: Jane Doe\nRefs: #123\nCloses #12\n",
+			footers: []Footer{
+				{Token: "Reviewed-by", Value: "Jane Doe"},
+				{Token: "Refs", Value: "#123"},
+				{Token: "Closes", Value: "#12"},
+			},
+		},
+		{
+			body: "a body\n\nBREAKING CHANGE: a description\nthat spans lines\nSigned-off-by: John\n",
+			footers: []Footer{
+				{Token: "BREAKING CHANGE", Value: "a description\nthat spans lines"},
+				{Token: "Signed-off-by", Value: "John"},
+			},
+		},
+		{
+			// only the final paragraph is the trailer block
+			body: "Note: the cache is rebuilt on start.\nThis takes a while.\n\nSigned-off-by: John\n",
+			footers: []Footer{
+				{Token: "Signed-off-by", Value: "John"},
+			},
+		},
+		{
+			body: "Closes #12\n\nthe final paragraph is prose\n",
+		},
+		{
+			body: "a body\n\nBREAKING CHANGE: a description\n\nthat spans paragraphs\n\nSigned-off-by: John\n",
+			footers: []Footer{
+				{Token: "BREAKING CHANGE", Value: "a description\n\nthat spans paragraphs"},
+				{Token: "Signed-off-by", Value: "John"},
+			},
+		},
+		{
+			body: "Refs: #12\n",
+			footers: []Footer{
+				{Token: "Refs", Value: "#12"},
+			},
+		},
+	}
+	for i, row := range table {
+		footers := ParseFooters(row.body)
+		if !reflect.DeepEqual(footers, row.footers) {
+			t.Fatalf("[%d] expected\n%#v\ngot\n%#v", i, row.footers, footers)
+		}
+	}
+}
+
+func TestCommitFooter(t *testing.T) {
+	commit := &Commit{
+		Footers: []Footer{
+			{Token: "Refs", Value: "#1"},
+			{Token: "Reviewed-by", Value: "Jane"},
+		},
+	}
+	value, ok := commit.Footer("reviewed-by")
+	if !ok || value != "Jane" {
+		t.Fatalf("expected Jane, got %#v", value)
+	}
+	_, ok = commit.Footer("Closes")
+	if ok {
+		t.Fatalf("unexpected footer Closes")
+	}
+}
@@ -0,0 +1,569 @@
+package repository
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+)
+
+// ErrUnsupported is returned if the NativeBackend does not support an operation
+var ErrUnsupported = errors.New("not supported by the native git backend, use the exec backend")
+
+// ErrNoIdentity is returned if neither the environment nor the git config
+// define the name and email of the author or committer
+var ErrNoIdentity = errors.New("no git identity: set user.name and user.email")
+
+// NativeBackend reads and writes the object database of the repository
+// at Path directly, so no git binary is needed and commit messages are
+// not parsed from text output.
+// Revisions are resolved like `git rev-parse` does, ranges support `a..b`
+// and `^a b`. The commits are listed by commit date like `git log` does,
+// paths select the commits that differ from their first parent at one of the paths.
+// Signed tags are not supported
+type NativeBackend struct {
+	Path string
+	repo *git.Repository
+	// prefix is the Path relative to the root of the worktree
+	prefix string
+}
+
+// open opens the repository on first use
+func (b *NativeBackend) open() (*git.Repository, error) {
+	if b.repo != nil {
+		return b.repo, nil
+	}
+	repo, err := git.PlainOpenWithOptions(b.Path, &git.PlainOpenOptions{
+		DetectDotGit:          true,
+		EnableDotGitCommonDir: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	worktree, err := repo.Worktree()
+	if err == nil {
+		b.prefix, err = relativePath(worktree.Filesystem.Root(), b.Path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	b.repo = repo
+	return repo, nil
+}
+
+// relativePath returns the slash separated path of target relative to root
+func relativePath(root, target string) (string, error) {
+	for _, p := range []*string{&root, &target} {
+		abs, err := filepath.Abs(*p)
+		if err != nil {
+			return "", err
+		}
+		if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+			abs = resolved
+		}
+		*p = abs
+	}
+	rel, err := filepath.Rel(root, target)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// repoPath returns the path relative to the root of the worktree
+// of a path relative to the Path of the backend, the root itself is empty
+func (b *NativeBackend) repoPath(name string) string {
+	p := path.Join(b.prefix, filepath.ToSlash(name))
+	if p == "." {
+		return ""
+	}
+	return p
+}
+
+// resolve returns the commit of the revision
+func (b *NativeBackend) resolve(revision string) (*object.Commit, error) {
+	repo, err := b.open()
+	if err != nil {
+		return nil, err
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(revision))
+	if err != nil {
+		return nil, fmt.Errorf("unknown revision %s: %v", revision, err)
+	}
+	return repo.CommitObject(*hash)
+}
+
+// parent returns the commit with the given hash
+// or nil if it is missing, e.g. in a shallow clone
+func (b *NativeBackend) parent(hash plumbing.Hash) (*object.Commit, error) {
+	commit, err := b.repo.CommitObject(hash)
+	if err == plumbing.ErrObjectNotFound {
+		return nil, nil
+	}
+	return commit, err
+}
+
+// parseRevisions splits revisions like `a..b` or `^a b`
+// into the revisions to include and the ones to exclude.
+// An empty side of a range is HEAD
+func parseRevisions(revisions string) (include, exclude []string, err error) {
+	for _, rev := range strings.Fields(revisions) {
+		switch {
+		case strings.Contains(rev, "..."):
+			return nil, nil, fmt.Errorf("revision %s: symmetric differences are %v", rev, ErrUnsupported)
+		case strings.Contains(rev, ".."):
+			sides := strings.SplitN(rev, "..", 2)
+			for i, side := range sides {
+				if side == "" {
+					sides[i] = "HEAD"
+				}
+			}
+			exclude = append(exclude, sides[0])
+			include = append(include, sides[1])
+		case strings.HasPrefix(rev, "^"):
+			exclude = append(exclude, rev[1:])
+		default:
+			include = append(include, rev)
+		}
+	}
+	if len(include) == 0 {
+		include = []string{"HEAD"}
+	}
+	return include, exclude, nil
+}
+
+// ancestors adds the commit and all of its ancestors to the set
+func (b *NativeBackend) ancestors(commit *object.Commit, set map[plumbing.Hash]bool) error {
+	stack := []*object.Commit{commit}
+	for len(stack) > 0 {
+		commit, stack = stack[len(stack)-1], stack[:len(stack)-1]
+		if set[commit.Hash] {
+			continue
+		}
+		set[commit.Hash] = true
+		for _, hash := range commit.ParentHashes {
+			if set[hash] {
+				continue
+			}
+			parent, err := b.parent(hash)
+			if err != nil {
+				return err
+			}
+			if parent != nil {
+				stack = append(stack, parent)
+			}
+		}
+	}
+	return nil
+}
+
+// Log walks the commits from the included revisions, the latest commit date first
+func (b *NativeBackend) Log(opts LogOptions) ([]RawCommit, error) {
+	include, exclude, err := parseRevisions(opts.Revisions)
+	if err != nil {
+		return nil, err
+	}
+	excluded := make(map[plumbing.Hash]bool)
+	for _, rev := range exclude {
+		commit, err := b.resolve(rev)
+		if err != nil {
+			return nil, err
+		}
+		err = b.ancestors(commit, excluded)
+		if err != nil {
+			return nil, err
+		}
+	}
+	queue := &commitQueue{}
+	seen := make(map[plumbing.Hash]bool)
+	for _, rev := range include {
+		commit, err := b.resolve(rev)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[commit.Hash] && !excluded[commit.Hash] {
+			seen[commit.Hash] = true
+			queue.push(commit)
+		}
+	}
+	var commits []RawCommit
+	for queue.Len() > 0 && (opts.Max == 0 || len(commits) < opts.Max) {
+		commit := heap.Pop(queue).(queuedCommit).commit
+		parents := commit.ParentHashes
+		if opts.Mode == FirstParent && len(parents) > 1 {
+			parents = parents[:1]
+		}
+		for _, hash := range parents {
+			if seen[hash] || excluded[hash] {
+				continue
+			}
+			seen[hash] = true
+			parent, err := b.parent(hash)
+			if err != nil {
+				return nil, err
+			}
+			if parent != nil {
+				queue.push(parent)
+			}
+		}
+		if !opts.Mode.selects(len(commit.ParentHashes)) {
+			continue
+		}
+		touched, err := b.touches(commit, opts.Paths)
+		if err != nil {
+			return nil, err
+		}
+		if touched {
+			commits = append(commits, rawCommit(commit))
+		}
+	}
+	return commits, nil
+}
+
+// touches reports whether the commit differs from its first parent
+// at one of the paths. Every commit touches an empty list of paths
+func (b *NativeBackend) touches(commit *object.Commit, paths []string) (bool, error) {
+	if len(paths) == 0 {
+		return true, nil
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return false, err
+	}
+	var parentTree *object.Tree
+	if len(commit.ParentHashes) > 0 {
+		parent, err := b.parent(commit.ParentHashes[0])
+		if err != nil {
+			return false, err
+		}
+		if parent != nil {
+			parentTree, err = parent.Tree()
+			if err != nil {
+				return false, err
+			}
+		}
+	}
+	for _, p := range paths {
+		p = b.repoPath(p)
+		if entryHash(tree, p) != entryHash(parentTree, p) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// entryHash returns the hash of the file or directory at the path
+// or the zero hash if there is none
+func entryHash(tree *object.Tree, p string) plumbing.Hash {
+	if tree == nil {
+		return plumbing.ZeroHash
+	}
+	if p == "" {
+		return tree.Hash
+	}
+	entry, err := tree.FindEntry(p)
+	if err != nil {
+		return plumbing.ZeroHash
+	}
+	return entry.Hash
+}
+
+// rawCommit converts a commit object into a RawCommit
+func rawCommit(commit *object.Commit) RawCommit {
+	parents := make([]string, 0, len(commit.ParentHashes))
+	for _, hash := range commit.ParentHashes {
+		parents = append(parents, hash.String())
+	}
+	subject, body := splitMessage(commit.Message)
+	return RawCommit{
+		Hash:         commit.Hash.String(),
+		ParentHashes: parents,
+		Date:         time.Unix(commit.Author.When.Unix(), 0),
+		Author: CommitAuthor{
+			Name:  commit.Author.Name,
+			Email: commit.Author.Email,
+		},
+		Subject: subject,
+		Body:    body,
+	}
+}
+
+// splitMessage splits a commit message like the `%s` and `%b`
+// placeholders of git log: the subject is the first paragraph
+// joined into a single line, the body is the rest of the message
+func splitMessage(message string) (subject, body string) {
+	lines := strings.Split(strings.TrimLeft(message, "\n"), "\n")
+	i := 0
+	var subjectLines []string
+	for i < len(lines) && strings.TrimSpace(lines[i]) != "" {
+		subjectLines = append(subjectLines, strings.TrimSpace(lines[i]))
+		i++
+	}
+	for i < len(lines) && strings.TrimSpace(lines[i]) == "" {
+		i++
+	}
+	body = strings.TrimRight(strings.Join(lines[i:], "\n"), "\n")
+	if body != "" {
+		body += "\n"
+	}
+	// the ExecBackend adds a newline to the body as well
+	return strings.Join(subjectLines, " "), body + "\n"
+}
+
+// queuedCommit is a commit in the commitQueue,
+// commits with the same date keep the order in which they were queued
+type queuedCommit struct {
+	commit *object.Commit
+	seq    int
+}
+
+// commitQueue is a priority queue of commits, the latest commit date first
+type commitQueue struct {
+	commits []queuedCommit
+	seq     int
+}
+
+func (q *commitQueue) push(commit *object.Commit) {
+	q.seq++
+	heap.Push(q, queuedCommit{commit: commit, seq: q.seq})
+}
+
+func (q *commitQueue) Len() int      { return len(q.commits) }
+func (q *commitQueue) Swap(i, j int) { q.commits[i], q.commits[j] = q.commits[j], q.commits[i] }
+func (q *commitQueue) Less(i, j int) bool {
+	a, b := q.commits[i], q.commits[j]
+	if !a.commit.Committer.When.Equal(b.commit.Committer.When) {
+		return a.commit.Committer.When.After(b.commit.Committer.When)
+	}
+	return a.seq < b.seq
+}
+func (q *commitQueue) Push(x interface{}) { q.commits = append(q.commits, x.(queuedCommit)) }
+func (q *commitQueue) Pop() interface{} {
+	last := q.commits[len(q.commits)-1]
+	q.commits = q.commits[:len(q.commits)-1]
+	return last
+}
+
+// Show reads the file from the tree of the revision
+func (b *NativeBackend) Show(revision, filename string) ([]byte, error) {
+	commit, err := b.resolve(revision)
+	if err != nil {
+		return nil, err
+	}
+	file, err := commit.File(b.repoPath(filename))
+	if err != nil {
+		return nil, fmt.Errorf("%s:%s: %v", revision, filename, err)
+	}
+	content, err := file.Contents()
+	return []byte(content), err
+}
+
+// RevParse resolves the revision to a commit
+func (b *NativeBackend) RevParse(revision string) (string, error) {
+	commit, err := b.resolve(revision)
+	if err != nil {
+		return "", err
+	}
+	return commit.Hash.String(), nil
+}
+
+// MergedTags returns the tags whose commit is an ancestor of HEAD
+func (b *NativeBackend) MergedTags() ([]RawTag, error) {
+	head, err := b.resolve("HEAD")
+	if err != nil {
+		return nil, err
+	}
+	merged := make(map[plumbing.Hash]bool)
+	err = b.ancestors(head, merged)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := b.repo.Tags()
+	if err != nil {
+		return nil, err
+	}
+	var tags []RawTag
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		hash := ref.Hash()
+		if tag, err := b.repo.TagObject(hash); err == nil {
+			commit, err := tag.Commit()
+			if err != nil {
+				// tags of trees and blobs are no releases
+				return nil
+			}
+			hash = commit.Hash
+		}
+		if merged[hash] {
+			tags = append(tags, RawTag{Name: ref.Name().Short(), Hash: hash.String()})
+		}
+		return nil
+	})
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i].Name < tags[j].Name
+	})
+	return tags, err
+}
+
+// RemoteURL returns the first URL of the remote
+func (b *NativeBackend) RemoteURL(name string) (string, error) {
+	repo, err := b.open()
+	if err != nil {
+		return "", err
+	}
+	remote, err := repo.Remote(name)
+	if err != nil {
+		return "", err
+	}
+	urls := remote.Config().URLs
+	if len(urls) == 0 {
+		return "", fmt.Errorf("remote %s has no url", name)
+	}
+	return urls[0], nil
+}
+
+// GitPath joins the name with the git directory, core.hooksPath is respected
+func (b *NativeBackend) GitPath(name string) (string, error) {
+	repo, err := b.open()
+	if err != nil {
+		return "", err
+	}
+	if name == "hooks" {
+		cfg, err := repo.Config()
+		if err != nil {
+			return "", err
+		}
+		if hooksPath := cfg.Raw.Section("core").Option("hooksPath"); hooksPath != "" {
+			if filepath.IsAbs(hooksPath) {
+				return hooksPath, nil
+			}
+			worktree, err := repo.Worktree()
+			if err != nil {
+				return "", err
+			}
+			return filepath.Join(worktree.Filesystem.Root(), hooksPath), nil
+		}
+	}
+	storage, ok := repo.Storer.(*filesystem.Storage)
+	if !ok {
+		return "", fmt.Errorf("git path %s: %v", name, ErrUnsupported)
+	}
+	return filepath.Join(storage.Filesystem().Root(), name), nil
+}
+
+// Fetch fetches all remotes
+func (b *NativeBackend) Fetch() error {
+	repo, err := b.open()
+	if err != nil {
+		return err
+	}
+	remotes, err := repo.Remotes()
+	if err != nil {
+		return err
+	}
+	for _, remote := range remotes {
+		err = remote.Fetch(&git.FetchOptions{})
+		if err != nil && err != git.NoErrAlreadyUpToDate {
+			return fmt.Errorf("fetch %s: %v", remote.Config().Name, err)
+		}
+	}
+	return nil
+}
+
+// Add stages the files
+func (b *NativeBackend) Add(files ...string) error {
+	repo, err := b.open()
+	if err != nil {
+		return err
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		_, err = worktree.Add(b.repoPath(file))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Commit commits the staged changes, surrounding whitespace of the message is removed
+func (b *NativeBackend) Commit(message string) error {
+	repo, err := b.open()
+	if err != nil {
+		return err
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+	author, err := b.signature("AUTHOR")
+	if err != nil {
+		return err
+	}
+	committer, err := b.signature("COMMITTER")
+	if err != nil {
+		return err
+	}
+	_, err = worktree.Commit(strings.TrimSpace(message)+"\n", &git.CommitOptions{
+		Author:    author,
+		Committer: committer,
+	})
+	return err
+}
+
+// CreateTag creates an annotated tag at HEAD, signing is not supported
+func (b *NativeBackend) CreateTag(name, message string, sign bool) error {
+	if sign {
+		return fmt.Errorf("signed tags are %v", ErrUnsupported)
+	}
+	head, err := b.resolve("HEAD")
+	if err != nil {
+		return err
+	}
+	tagger, err := b.signature("COMMITTER")
+	if err != nil {
+		return err
+	}
+	_, err = b.repo.CreateTag(name, head.Hash, &git.CreateTagOptions{
+		Tagger:  tagger,
+		Message: strings.TrimSpace(message) + "\n",
+	})
+	return err
+}
+
+// signature returns the identity of the author or committer.
+// Like git, the GIT_AUTHOR_* and GIT_COMMITTER_* environment variables
+// take precedence over user.name and user.email of the git config
+func (b *NativeBackend) signature(role string) (*object.Signature, error) {
+	name := os.Getenv("GIT_" + role + "_NAME")
+	email := os.Getenv("GIT_" + role + "_EMAIL")
+	if name == "" || email == "" {
+		cfg, err := b.repo.ConfigScoped(config.SystemScope)
+		if err != nil {
+			return nil, err
+		}
+		if name == "" {
+			name = cfg.User.Name
+		}
+		if email == "" {
+			email = cfg.User.Email
+		}
+	}
+	if name == "" || email == "" {
+		return nil, ErrNoIdentity
+	}
+	return &object.Signature{Name: name, Email: email, When: time.Now()}, nil
+}
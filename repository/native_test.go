@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNativeBackendLog(t *testing.T) {
+	repoPath := createRepository()
+	os.Mkdir(path.Join(repoPath, "lib"), os.ModePerm)
+	createAndCommit(path.Join(repoPath, "lib"), "feat(lib): add lib\n\nwith a body\n\nCloses #12")
+	execDir(repoPath, "git", "checkout", "-b", "feature")
+	createAndCommit(repoPath, "wip")
+	createAndCommit(path.Join(repoPath, "lib"), "fix(lib): on the branch")
+	execDir(repoPath, "git", "checkout", "master")
+	createAndCommit(repoPath, "fix: direct (#3)")
+	execDir(repoPath, "git", "merge", "--no-ff", "feature", "-m", "Merge pull request #5 from o/feature", "-m", "feat: merged feature")
+	execDir(repoPath, "git", "tag", "-a", "v1.1.0", "-m", "release")
+	createAndCommit(repoPath, "a subject\nthat wraps")
+
+	exec := &ExecBackend{Path: repoPath}
+	native := &NativeBackend{Path: repoPath}
+	table := []LogOptions{
+		{Revisions: "HEAD"},
+		{Revisions: "HEAD", Mode: NoMerges},
+		{Revisions: "HEAD", Mode: MergesOnly},
+		{Revisions: "HEAD", Mode: FirstParent},
+		{Revisions: "1.0.0..HEAD", Mode: NoMerges},
+		{Revisions: "1.0.0..v1.1.0", Mode: FirstParent},
+		{Revisions: "v1.1.0..", Mode: NoMerges},
+		{Revisions: "HEAD~1", Mode: AllCommits, Max: 1},
+		{Revisions: "HEAD", Mode: NoMerges, Paths: []string{"lib"}},
+		{Revisions: "HEAD", Mode: FirstParent, Paths: []string{"lib"}},
+		{Mode: NoMerges, Paths: []string{"VERSION"}, Max: 1},
+	}
+	for i, opts := range table {
+		expected, err := exec.Log(opts)
+		if err != nil {
+			t.Fatalf("[%d] exec error: %v", i, err)
+		}
+		commits, err := native.Log(opts)
+		if err != nil {
+			t.Fatalf("[%d] native error: %v", i, err)
+		}
+		if !reflect.DeepEqual(commits, expected) {
+			t.Fatalf("[%d] expected\n%#v\ngot\n%#v", i, expected, commits)
+		}
+	}
+
+	// a package in a sub directory
+	sub := &NativeBackend{Path: path.Join(repoPath, "lib")}
+	commits, err := sub.Log(LogOptions{Revisions: "HEAD", Paths: []string{"."}})
+	if err != nil || len(commits) != 2 {
+		t.Fatalf("unexpected commits %#v: %v", commits, err)
+	}
+
+	for _, revisions := range []string{"doesnotexist", "HEAD...1.0.0"} {
+		if _, err := native.Log(LogOptions{Revisions: revisions}); err == nil {
+			t.Fatalf("expected error for %s", revisions)
+		}
+	}
+}
+
+func TestNativeBackendSeparators(t *testing.T) {
+	repoPath := createRepository()
+	body := "body\n" + bodyEndSeperator + "\n" + delimiter + "\n\nCloses #3"
+	createAndCommit(repoPath, "fix: "+delimiter+" in the subject\n\n"+body)
+	repo := New(repoPath, DefaultMapFunc)
+	repo.Backend = &NativeBackend{Path: repoPath}
+	commits, err := repo.GetHistoryUntil("1.0.0")
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if len(commits) != 1 || commits[0].Subject != delimiter+" in the subject" ||
+		commits[0].Body != body+"\n\n" || !reflect.DeepEqual(commits[0].Footers, []Footer{{Token: "Closes", Value: "#3"}}) {
+		t.Fatalf("unexpected commits %#v", commits[0])
+	}
+}
+
+func TestNativeBackendRepository(t *testing.T) {
+	repoPath := createRepository()
+	execDir(repoPath, "git", "config", "user.name", "asdf")
+	execDir(repoPath, "git", "config", "user.email", "asdf@example.com")
+	repo := New(repoPath, DefaultMapFunc)
+	repo.Backend = &NativeBackend{Path: repoPath}
+	createVersionFile(repoPath, "1.1.0")
+	err := repo.Add("VERSION")
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	hash, err := repo.Commit("chore(release): 1.1.0\n")
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	commit, err := repo.LatestChangeOfFile("VERSION")
+	if err != nil || commit.Hash != hash || commit.Subject != "1.1.0" || commit.Type != "chore" {
+		t.Fatalf("unexpected release commit %#v: %v", commit, err)
+	}
+	err = repo.CreateTag("v1.1.0", "## 1.1.0\n\n* release notes", false)
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if err = repo.CreateTag("v1.1.1", "signed", true); err == nil {
+		t.Fatal("expected an error for signed tags")
+	}
+	tags, err := repo.VersionTags("v")
+	if err != nil || len(tags) != 1 || tags[0].Name != "v1.1.0" || tags[0].Hash != hash {
+		t.Fatalf("unexpected tags %#v: %v", tags, err)
+	}
+	out, _, err := execDir(repoPath, "git", "tag", "-l", "--format=%(contents)", "v1.1.0")
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	message, _ := ioutil.ReadAll(out)
+	if strings.TrimSpace(string(message)) != "## 1.1.0\n\n* release notes" {
+		t.Fatalf("unexpected tag message: %#v", string(message))
+	}
+
+	content, err := repo.FileAtRevision("1.0.0", "VERSION")
+	if err != nil || string(content) != "1.0.0" {
+		t.Fatalf("unexpected content %q: %v", content, err)
+	}
+	if _, err = repo.RevParse("doesnotexist"); err == nil {
+		t.Fatal("expected an error for an unknown revision")
+	}
+	expected, _ := New(repoPath, DefaultMapFunc).RemoteURL("origin")
+	url, err := repo.RemoteURL("origin")
+	if err != nil || url != expected {
+		t.Fatalf("expected remote url %s, got %s: %v", expected, url, err)
+	}
+	expected, _ = New(repoPath, DefaultMapFunc).GitPath("hooks")
+	hooks, err := repo.GitPath("hooks")
+	if err != nil || hooks != expected {
+		t.Fatalf("expected hooks %s, got %s: %v", expected, hooks, err)
+	}
+}
+
+func TestParseBackendKind(t *testing.T) {
+	for _, s := range []string{"", "auto", "Exec", "native"} {
+		if _, err := ParseBackendKind(s); err != nil {
+			t.Fatalf("unexpected error for %q: %v", s, err)
+		}
+	}
+	if _, err := ParseBackendKind("libgit2"); err != ErrUnknownBackend {
+		t.Fatalf("expected ErrUnknownBackend, got %v", err)
+	}
+}
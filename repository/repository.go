@@ -1,13 +1,8 @@
 package repository
 
-import (
-	"bytes"
-	"errors"
-	"io"
-	"os/exec"
-)
-
-// ErrExec is returned if a git command fails.
+import "errors"
+
+// ErrExec is matched by the ExecError of a failed git command.
 // That might happen if the command is executed in the wrong directory
 // or the git command is not found
 var ErrExec = errors.New("git command failed")
@@ -22,23 +17,46 @@ var ErrNoHistory = errors.New("no history found")
 type GitRepository struct {
 	Path          string
 	CommitMapFunc CommitMapFunc
+	ChangeFunc    ChangeFunc
+	// Paths restricts the history to commits that touch one of the paths
+	Paths []string
+	// Mode selects the commits of the history, defaults to NoMerges
+	Mode HistoryMode
+	// Backend accesses the git repository, defaults to an ExecBackend
+	Backend Backend
 }
 
 // New creates a new Repository
+// which uses the DefaultChangeFunc to determine the Change of a commit
 func New(repoPath string, mapFunc CommitMapFunc) *GitRepository {
 	return &GitRepository{
 		Path:          repoPath,
 		CommitMapFunc: mapFunc,
+		ChangeFunc:    DefaultChangeFunc,
+		Backend:       &ExecBackend{Path: repoPath},
 	}
 }
 
-// LatestChangeOfFile gives us the commit of the latest change of that file
-func (r *GitRepository) LatestChangeOfFile(filename string) (*Commit, error) {
-	out, _, err := execDir(r.Path, "git", "log", "--no-merges", "-n1", "--format="+logFormatter, "--", filename)
+// backend returns the Backend of the repository
+func (r *GitRepository) backend() Backend {
+	if r.Backend == nil {
+		return &ExecBackend{Path: r.Path}
+	}
+	return r.Backend
+}
+
+// log returns the commits selected by the options
+func (r *GitRepository) log(opts LogOptions) (Commits, error) {
+	raws, err := r.backend().Log(opts)
 	if err != nil {
 		return nil, err
 	}
-	commits, err := ParseCommits(out, r.CommitMapFunc)
+	return mapCommits(raws, r.CommitMapFunc, r.ChangeFunc), nil
+}
+
+// LatestChangeOfFile gives us the commit of the latest change of that file
+func (r *GitRepository) LatestChangeOfFile(filename string) (*Commit, error) {
+	commits, err := r.log(LogOptions{Mode: NoMerges, Paths: []string{filename}, Max: 1})
 	if err != nil {
 		return nil, err
 	}
@@ -49,14 +67,33 @@ func (r *GitRepository) LatestChangeOfFile(filename string) (*Commit, error) {
 	return commit, nil
 }
 
-// GetHistoryUntil returns all commits from HEAD to the specified commit
-func (r *GitRepository) GetHistoryUntil(revision string) (Commits, error) {
-	var commits Commits
-	out, _, err := execDir(r.Path, "git", "log", "--no-merges", "--format="+logFormatter, revision+"..HEAD")
+// ChangesOfFile returns all commits that changed the file, the latest commit comes first
+func (r *GitRepository) ChangesOfFile(filename string) (Commits, error) {
+	return r.log(LogOptions{Mode: NoMerges, Paths: []string{filename}})
+}
+
+// FileAtRevision returns the content of the file at the given revision.
+// The filename is relative to the path of the repository
+func (r *GitRepository) FileAtRevision(revision, filename string) ([]byte, error) {
+	return r.backend().Show(revision, filename)
+}
+
+// LookupCommit returns the commit of the given revision
+// regardless of the Paths of the repository
+func (r *GitRepository) LookupCommit(revision string) (*Commit, error) {
+	commits, err := r.log(LogOptions{Revisions: revision, Mode: AllCommits, Max: 1})
 	if err != nil {
-		return commits, err
+		return nil, err
+	}
+	if len(commits) == 0 {
+		return nil, ErrNoHistory
 	}
-	return ParseCommits(out, r.CommitMapFunc)
+	return commits[0], nil
+}
+
+// GetHistoryUntil returns all commits from HEAD to the specified commit
+func (r *GitRepository) GetHistoryUntil(revision string) (Commits, error) {
+	return r.GetHistory(revision + "..HEAD")
 }
 
 // GetHistory returns all commits defined by a gitrevision
@@ -66,25 +103,47 @@ func (r *GitRepository) GetHistoryUntil(revision string) (Commits, error) {
 // - "HEAD^1"
 // For further information read `man 7 gitrevisions`
 func (r *GitRepository) GetHistory(gitrevisions string) (Commits, error) {
-	var commits Commits
-	out, _, err := execDir(r.Path, "git", "log", "--no-merges", "--format="+logFormatter, gitrevisions)
-	if err != nil {
-		return commits, err
-	}
-	return ParseCommits(out, r.CommitMapFunc)
-}
-
-// execDir executes a command in a specific directory
-func execDir(dir, cmd string, things ...string) (io.Reader, io.Reader, error) {
-	var stdout bytes.Buffer
-	var stderr bytes.Buffer
-	c := exec.Command(cmd, things...)
-	c.Dir = dir
-	c.Stdout = &stdout
-	c.Stderr = &stderr
-	err := c.Run()
+	return r.log(LogOptions{Revisions: gitrevisions, Mode: r.Mode, Paths: r.Paths})
+}
+
+// Add stages the given files
+func (r *GitRepository) Add(files ...string) error {
+	return r.backend().Add(files...)
+}
+
+// Commit commits the staged changes and returns the hash of the new commit
+func (r *GitRepository) Commit(message string) (string, error) {
+	err := r.backend().Commit(message)
 	if err != nil {
-		return nil, nil, ErrExec
+		return "", err
 	}
-	return &stdout, &stderr, nil
+	return r.RevParse("HEAD")
+}
+
+// CreateTag creates an annotated tag with the given message at HEAD.
+// If sign is true the tag is signed with the default GPG key.
+// Lines starting with `#` are kept, so the message may contain markdown headers
+func (r *GitRepository) CreateTag(name, message string, sign bool) error {
+	return r.backend().CreateTag(name, message, sign)
+}
+
+// RevParse returns the commit hash of the given revision
+func (r *GitRepository) RevParse(revision string) (string, error) {
+	return r.backend().RevParse(revision)
+}
+
+// RemoteURL returns the URL of the remote with the given name
+func (r *GitRepository) RemoteURL(name string) (string, error) {
+	return r.backend().RemoteURL(name)
+}
+
+// GitPath returns the absolute path of a file in the git directory,
+// e.g. `hooks`. Worktrees and configured paths like core.hooksPath are respected
+func (r *GitRepository) GitPath(name string) (string, error) {
+	return r.backend().GitPath(name)
+}
+
+// Fetch fetches the branches and tags of all remotes
+func (r *GitRepository) Fetch() error {
+	return r.backend().Fetch()
 }
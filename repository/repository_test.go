@@ -1,9 +1,11 @@
 package repository
 
 import (
+	"errors"
 	"io/ioutil"
 	"os"
 	"path"
+	"strings"
 	"testing"
 )
 
@@ -23,7 +25,7 @@ func TestLatestChangeOfFileFail(t *testing.T) {
 	repoPath, _ := ioutil.TempDir("", "bh")
 	repo := New(repoPath, DefaultMapFunc)
 	commit, err := repo.LatestChangeOfFile("VERSION")
-	if err != ErrExec {
+	if !errors.Is(err, ErrExec) {
 		t.Fatalf("expected ErrExec, got: %v", err)
 	}
 	if commit != nil {
@@ -77,7 +79,7 @@ func TestGetHistoryExecErr(t *testing.T) {
 	repoPath, _ := ioutil.TempDir("", "bads")
 	repo := New(repoPath, DefaultMapFunc)
 	commits, err := repo.GetHistoryUntil("")
-	if err != ErrExec {
+	if !errors.Is(err, ErrExec) {
 		t.Fatalf("expected ErrExec, got: %v", err)
 	}
 	if commits != nil {
@@ -115,3 +117,102 @@ func createVersionFile(repo, version string) {
 		panic(err)
 	}
 }
+
+func TestCommitAndTag(t *testing.T) {
+	repoPath := createRepository()
+	repo := New(repoPath, DefaultMapFunc)
+	createVersionFile(repoPath, "1.1.0")
+	err := repo.Add("VERSION")
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	hash, err := repo.Commit("chore(release): 1.1.0")
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	commit, err := repo.LatestChangeOfFile("VERSION")
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if commit.Hash != hash || commit.Subject != "1.1.0" || commit.Type != "chore" {
+		t.Fatalf("unexpected release commit: %#v", commit)
+	}
+	err = repo.CreateTag("v1.1.0", "## 1.1.0\n\n* release notes", false)
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	tag, err := repo.LatestVersionTag("v")
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if tag.Name != "v1.1.0" || tag.Hash != hash {
+		t.Fatalf("unexpected tag: %#v", tag)
+	}
+	out, _, err := execDir(repoPath, "git", "tag", "-l", "--format=%(contents)", "v1.1.0")
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	message, _ := ioutil.ReadAll(out)
+	if strings.TrimSpace(string(message)) != "## 1.1.0\n\n* release notes" {
+		t.Fatalf("unexpected tag message: %#v", string(message))
+	}
+}
+
+func TestRevParseFail(t *testing.T) {
+	repo := New(createRepository(), DefaultMapFunc)
+	_, err := repo.RevParse("doesnotexist")
+	if !errors.Is(err, ErrExec) {
+		t.Fatalf("expected ErrExec, got: %v", err)
+	}
+	// the error contains the command and its error output
+	if !strings.Contains(err.Error(), "git rev-parse --verify doesnotexist^{commit}") || !strings.Contains(err.Error(), "fatal:") {
+		t.Fatalf("expected the command and its error output, got: %v", err)
+	}
+}
+
+func TestGetHistoryPaths(t *testing.T) {
+	repoPath := createRepository()
+	for _, dir := range []string{"a", "b"} {
+		os.Mkdir(path.Join(repoPath, dir), os.ModePerm)
+		createAndCommit(path.Join(repoPath, dir), "change in "+dir)
+	}
+	repo := New(repoPath, DefaultMapFunc)
+	repo.Paths = []string{"a"}
+	commits, err := repo.GetHistoryUntil("1.0.0")
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if len(commits) != 1 || commits[0].Subject != "change in a" {
+		t.Fatalf("unexpected commits: %#v", commits)
+	}
+	commits, err = repo.GetHistory("HEAD")
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if len(commits) != 1 || commits[0].Subject != "change in a" {
+		t.Fatalf("unexpected commits: %#v", commits)
+	}
+}
+
+func TestChangesOfFile(t *testing.T) {
+	repoPath := createRepository()
+	createAndCommit(repoPath, "unrelated")
+	createVersionFile(repoPath, "1.1.0")
+	createAndCommit(repoPath, "release 1.1.0")
+	repo := New(repoPath, DefaultMapFunc)
+	commits, err := repo.ChangesOfFile("VERSION")
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if len(commits) != 2 || commits[0].Subject != "release 1.1.0" || commits[1].Subject != "initial commit" {
+		t.Fatalf("unexpected commits: %#v", commits)
+	}
+	content, err := repo.FileAtRevision(commits[1].Hash, "VERSION")
+	if err != nil || string(content) != "1.0.0" {
+		t.Fatalf("unexpected content %q: %v", content, err)
+	}
+	commit, err := repo.LookupCommit("1.0.0")
+	if err != nil || commit.Hash != commits[1].Hash {
+		t.Fatalf("unexpected commit %#v: %v", commit, err)
+	}
+}
@@ -0,0 +1,72 @@
+package repository
+
+import "strings"
+
+// ChangeFunc is called for every parsed commit
+// and returns the Change the commit causes
+type ChangeFunc func(commit *Commit) Change
+
+// AnyType can be used as Rule.Type to match commits of every type
+const AnyType = "*"
+
+// Rule maps commits of a type and, optionally, a scope to a Change
+type Rule struct {
+	Type   string
+	Scope  string
+	Change Change
+}
+
+// Rules is a list of rules that determine the Change of a commit
+type Rules []Rule
+
+// DefaultRules contains the rules that are used if nothing else is specified.
+// Commits that do not match any rule cause a PatchChange
+var DefaultRules = Rules{
+	{Type: "breaking", Change: MajorChange},
+	{Type: "feat", Change: MinorChange},
+}
+
+// DefaultChangeFunc applies the DefaultRules
+func DefaultChangeFunc(commit *Commit) Change {
+	return DefaultRules.Change(commit)
+}
+
+// Change returns the Change of the given commit.
+// A breaking change is always a MajorChange. Otherwise the most specific
+// matching rule wins: a rule with a scope takes precedence over a rule for
+// the type only, which takes precedence over a rule for AnyType.
+// If multiple rules are equally specific, the first one wins
+func (rules Rules) Change(commit *Commit) Change {
+	if commit.Breaking {
+		return MajorChange
+	}
+	change := PatchChange
+	best := -1
+	for _, rule := range rules {
+		specificity := rule.specificity(commit)
+		if specificity > best {
+			best = specificity
+			change = rule.Change
+		}
+	}
+	return change
+}
+
+// specificity returns how specific the rule matches the commit
+// or -1 if it does not match at all
+func (rule Rule) specificity(commit *Commit) int {
+	specificity := 0
+	if rule.Type != AnyType {
+		if !strings.EqualFold(rule.Type, commit.Type) {
+			return -1
+		}
+		specificity++
+	}
+	if rule.Scope != "" {
+		if !strings.EqualFold(rule.Scope, commit.Scope) {
+			return -1
+		}
+		specificity += 2
+	}
+	return specificity
+}
@@ -0,0 +1,36 @@
+package repository
+
+import "testing"
+
+func TestRules(t *testing.T) {
+	rules := Rules{
+		{Type: "perf", Change: MinorChange},
+		{Type: "docs", Change: NoChange},
+		{Type: "fix", Scope: "security", Change: MinorChange},
+		{Type: AnyType, Scope: "deps", Change: NoChange},
+		{Type: "fix", Change: PatchChange},
+	}
+	rules = append(rules, DefaultRules...)
+	table := []struct {
+		commit *Commit
+		change Change
+	}{
+		{commit: &Commit{Type: "perf"}, change: MinorChange},
+		{commit: &Commit{Type: "docs"}, change: NoChange},
+		{commit: &Commit{Type: "docs", Breaking: true}, change: MajorChange},
+		{commit: &Commit{Type: "fix"}, change: PatchChange},
+		{commit: &Commit{Type: "fix", Scope: "SECURITY"}, change: MinorChange},
+		{commit: &Commit{Type: "chore", Scope: "deps"}, change: NoChange},
+		{commit: &Commit{Type: "feat", Scope: "deps"}, change: NoChange},
+		{commit: &Commit{Type: "feat"}, change: MinorChange},
+		{commit: &Commit{Type: "breaking"}, change: MajorChange},
+		{commit: &Commit{Type: "unknown"}, change: PatchChange},
+		{commit: &Commit{}, change: PatchChange},
+	}
+	for i, row := range table {
+		change := rules.Change(row.commit)
+		if change != row.change {
+			t.Fatalf("[%d] expected %s, got %s", i, row.change, change)
+		}
+	}
+}
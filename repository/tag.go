@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver"
+)
+
+// ErrNoTag is returned if no matching tag was found
+var ErrNoTag = errors.New("no tag found")
+
+// Tag is a git tag whose name contains a semver version
+type Tag struct {
+	Name    string
+	Hash    string
+	Version *semver.Version
+}
+
+// VersionTags returns all tags reachable from HEAD that consist of
+// the prefix followed by a semver version, e.g. `v1.2.3` or `mylib/v1.2.3`.
+// A leading `v` has to be part of the prefix, so an empty prefix does not match `v1.2.3`.
+// The tags are sorted by version, the highest version comes first
+func (r *GitRepository) VersionTags(prefix string) ([]*Tag, error) {
+	refs, err := r.backend().MergedTags()
+	if err != nil {
+		return nil, err
+	}
+	var tags []*Tag
+	for _, ref := range refs {
+		name := strings.TrimPrefix(ref.Name, prefix)
+		if !strings.HasPrefix(ref.Name, prefix) || strings.HasPrefix(name, "v") {
+			continue
+		}
+		version, err := semver.NewVersion(name)
+		if err != nil {
+			continue
+		}
+		tags = append(tags, &Tag{
+			Name:    ref.Name,
+			Hash:    ref.Hash,
+			Version: version,
+		})
+	}
+	sort.SliceStable(tags, func(i, j int) bool {
+		return tags[i].Version.GreaterThan(tags[j].Version)
+	})
+	return tags, nil
+}
+
+// LatestVersionTag returns the tag with the highest version
+// that is reachable from HEAD, see VersionTags
+func (r *GitRepository) LatestVersionTag(prefix string) (*Tag, error) {
+	tags, err := r.VersionTags(prefix)
+	if err != nil {
+		return nil, err
+	}
+	if len(tags) == 0 {
+		return nil, ErrNoTag
+	}
+	return tags[0], nil
+}
@@ -0,0 +1,90 @@
+package repository
+
+import "testing"
+
+func TestVersionTags(t *testing.T) {
+	repoPath := createRepository()
+	repo := New(repoPath, DefaultMapFunc)
+	createAndCommit(repoPath, "feat: first")
+	execDir(repoPath, "git", "tag", "v1.2.0")
+	execDir(repoPath, "git", "tag", "-a", "mylib/v3.0.0", "-m", "annotated")
+	createAndCommit(repoPath, "feat: second")
+	execDir(repoPath, "git", "tag", "v1.10.0")
+	execDir(repoPath, "git", "tag", "not-a-version")
+	head, _ := repo.GetHistory("HEAD^1..HEAD")
+
+	// tags on other branches are not reachable
+	execDir(repoPath, "git", "checkout", "-b", "other")
+	createAndCommit(repoPath, "feat: unreachable")
+	execDir(repoPath, "git", "tag", "v9.0.0")
+	execDir(repoPath, "git", "checkout", "master")
+
+	table := []struct {
+		prefix string
+		tags   []string
+		hash   string
+	}{
+		{
+			prefix: "v",
+			tags:   []string{"v1.10.0", "v1.2.0"},
+			hash:   head[0].Hash,
+		},
+		{
+			prefix: "mylib/v",
+			tags:   []string{"mylib/v3.0.0"},
+		},
+		{
+			prefix: "",
+			tags:   []string{"1.0.0"},
+		},
+		{
+			prefix: "other/v",
+		},
+		{
+			// the v has to be part of the prefix
+			prefix: "mylib/",
+		},
+	}
+	for i, row := range table {
+		tags, err := repo.VersionTags(row.prefix)
+		if err != nil {
+			t.Fatalf("[%d] error: %v", i, err)
+		}
+		if len(tags) != len(row.tags) {
+			t.Fatalf("[%d] expected %d tags, got %d", i, len(row.tags), len(tags))
+		}
+		for j, tag := range tags {
+			if tag.Name != row.tags[j] {
+				t.Fatalf("[%d] expected tag %s, got %s", i, row.tags[j], tag.Name)
+			}
+		}
+		latest, err := repo.LatestVersionTag(row.prefix)
+		if len(row.tags) == 0 {
+			if err != ErrNoTag {
+				t.Fatalf("[%d] expected ErrNoTag, got %v", i, err)
+			}
+			continue
+		}
+		if row.hash != "" && latest.Hash != row.hash {
+			t.Fatalf("[%d] expected hash %s, got %s", i, row.hash, latest.Hash)
+		}
+	}
+}
+
+func TestVersionTagsAnnotated(t *testing.T) {
+	repoPath := createRepository()
+	repo := New(repoPath, DefaultMapFunc)
+	createAndCommit(repoPath, "feat: first")
+	execDir(repoPath, "git", "tag", "-a", "v2.0.0", "-m", "annotated")
+	commits, _ := repo.GetHistory("HEAD^1..HEAD")
+	tag, err := repo.LatestVersionTag("v")
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if tag.Hash != commits[0].Hash {
+		t.Fatalf("expected the tagged commit %s, got %s", commits[0].Hash, tag.Hash)
+	}
+	if tag.Version.String() != "2.0.0" {
+		t.Fatalf("expected version 2.0.0, got %s", tag.Version)
+	}
+}
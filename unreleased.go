@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/moolen/asdf/changelog"
+	"github.com/urfave/cli"
+)
+
+// unreleased updates the Unreleased section of the changelog file of every
+// package with the commits since the latest release. Without commits the
+// section is removed. generate turns it into the section of the new version.
+// In dry-run mode a diff of the changelog is written to stdout
+func unreleasedCommand(c *cli.Context) error {
+	cwd, err := getCwd(c)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	cfg, err := loadConfig(c, cwd)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	log.Infof("working in dir: %s", cwd)
+	for _, pkgCfg := range cfg.packageConfigs() {
+		repo := pkgCfg.newRepository(cwd)
+		_, revision, err := latestRelease(repo, cwd, pkgCfg)
+		if err != nil {
+			return cli.NewExitError(err, 2)
+		}
+		commits, err := historySince(repo, revision)
+		if err != nil {
+			return cli.NewExitError(err, 2)
+		}
+		log.Infof("found %d unreleased commits", len(commits))
+		changelogfile := path.Join(cwd, pkgCfg.ChangelogFile)
+		currentChangelog, err := ioutil.ReadFile(changelogfile)
+		if err != nil && !os.IsNotExist(err) {
+			return cli.NewExitError(err, 3)
+		}
+		file := changelog.ParseFile(string(currentChangelog), pkgCfg.Marker)
+		if len(commits) == 0 {
+			file.Remove(changelog.UnreleasedTitle)
+		} else {
+			cl := pkgCfg.newChangelog()
+			cl.PreviousRef = revision
+			section, err := cl.Markdown(cl.Unreleased(commits))
+			if err != nil {
+				return cli.NewExitError(err, 3)
+			}
+			file.Set(changelog.UnreleasedTitle, section)
+		}
+		newChangelog := []byte(file.String())
+		if c.Bool(flagDryRun) {
+			if pkgCfg.packageName() != "" {
+				fmt.Fprintf(os.Stdout, "package: %s\n", pkgCfg.packageName())
+			}
+			os.Stdout.WriteString(unifiedDiff("a/"+pkgCfg.ChangelogFile, "b/"+pkgCfg.ChangelogFile, currentChangelog, newChangelog))
+			continue
+		}
+		if string(newChangelog) == string(currentChangelog) {
+			continue
+		}
+		err = writeFileAtomic(changelogfile, newChangelog)
+		if err != nil {
+			return cli.NewExitError(err, 4)
+		}
+	}
+	return nil
+}
+
+func unreleasedFlags() []cli.Flag {
+	return append([]cli.Flag{
+		cli.StringFlag{
+			Name:  flagFile,
+			Value: "VERSION",
+			Usage: "file that holds the version information",
+		},
+		cli.StringFlag{
+			Name:  flagChangelog,
+			Value: "CHANGELOG.md",
+			Usage: "file that holds the changelog",
+		},
+		templateFlag(),
+		cli.BoolFlag{
+			Name:  flagDryRun,
+			Usage: "do not write anything, print a diff of the changelog instead",
+		},
+	}, selectFlags(releaseFlags(), flagSource, flagTagPrefix, flagHistory, flagPackage)...)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli"
+)
+
+func TestUnreleasedCommand(t *testing.T) {
+	repo := createRepository()
+	run := func(command func(*cli.Context) error, flags []cli.Flag, args ...string) string {
+		flagSet := flag.NewFlagSet("", flag.ContinueOnError)
+		for _, flag := range append(flags, globalFlags()...) {
+			flag.Apply(flagSet)
+		}
+		flagSet.Parse(append(args, "--dir", repo))
+		err := command(cli.NewContext(&cli.App{}, flagSet, nil))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		changelog, _ := ioutil.ReadFile(path.Join(repo, "CHANGELOG.md"))
+		return string(changelog)
+	}
+
+	createAndCommit(repo, "feat: foobar", "")
+	changelog := run(unreleasedCommand, unreleasedFlags())
+	if !strings.HasPrefix(changelog, "## Unreleased\n\n#### Feature\n\n* foobar (") {
+		t.Fatalf("unexpected changelog:\n%s", changelog)
+	}
+	createAndCommit(repo, "fix: barfoo", "")
+	changelog = run(unreleasedCommand, unreleasedFlags())
+	if strings.Count(changelog, "## Unreleased") != 1 || !strings.Contains(changelog, "* barfoo (") {
+		t.Fatalf("expected the unreleased section to be updated:\n%s", changelog)
+	}
+
+	changelog = run(generateCommand, generateFlags(), "--commit")
+	if !strings.HasPrefix(changelog, "## 1.1.0 (") || strings.Contains(changelog, "Unreleased") ||
+		strings.Count(changelog, "* foobar (") != 1 {
+		t.Fatalf("expected the unreleased section to be released:\n%s", changelog)
+	}
+	if released := run(unreleasedCommand, unreleasedFlags()); released != changelog {
+		t.Fatalf("expected no unreleased section without commits:\n%s", released)
+	}
+}
@@ -1,12 +1,16 @@
 package main
 
 import (
+	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
-	log "github.com/Sirupsen/logrus"
+	log "github.com/sirupsen/logrus"
 
 	"github.com/Masterminds/semver"
 	"github.com/moolen/asdf/repository"
@@ -17,6 +21,7 @@ func readVersionFile(path string) (*semver.Version, error) {
 	if err != nil {
 		return nil, err
 	}
+	defer file.Close()
 	return readVersion(file)
 }
 
@@ -25,8 +30,11 @@ func readVersion(rd io.Reader) (*semver.Version, error) {
 	if err != nil {
 		return nil, err
 	}
-	versionString := strings.TrimRight(string(content), "\n")
-	version := semver.MustParse(versionString)
+	versionString := strings.TrimSpace(string(content))
+	version, err := semver.NewVersion(versionString)
+	if err != nil {
+		return nil, errNoSemverVersion
+	}
 	return version, nil
 }
 
@@ -38,6 +46,105 @@ func nextReleaseByChange(latest *semver.Version, change repository.Change) semve
 	case repository.MinorChange:
 		log.Debugf("increment minor")
 		return latest.IncMinor()
+	case repository.PatchChange:
+		log.Debugf("increment patch")
+		return latest.IncPatch()
+	}
+	return *latest
+}
+
+// initialDevelopmentChange lowers the change while the major version is 0.
+// During the initial development breaking changes bump the minor
+// and features the patch version
+func initialDevelopmentChange(latest *semver.Version, change repository.Change) repository.Change {
+	if latest.Major() != 0 {
+		return change
+	}
+	switch change {
+	case repository.MajorChange:
+		return repository.MinorChange
+	case repository.MinorChange:
+		return repository.PatchChange
+	}
+	return change
+}
+
+// nextFinalVersion returns the next version without prerelease.
+// If the latest version is a prerelease, it is promoted as long as
+// it already covers the change, e.g. 1.3.0-rc.2 becomes 1.3.0 for
+// a minor or patch change but 2.0.0 for a major change
+func nextFinalVersion(latest *semver.Version, change repository.Change) semver.Version {
+	if latest.Prerelease() == "" {
+		return nextReleaseByChange(latest, change)
+	}
+	final := semver.MustParse(fmt.Sprintf("%d.%d.%d", latest.Major(), latest.Minor(), latest.Patch()))
+	if change > coveredChange(final) {
+		return nextReleaseByChange(final, change)
+	}
+	return *final
+}
+
+// coveredChange returns the largest change that leads to the given version
+// e.g. 2.0.0 covers a major change, 1.3.0 a minor change
+func coveredChange(version *semver.Version) repository.Change {
+	if version.Patch() != 0 {
+		return repository.PatchChange
+	}
+	if version.Minor() != 0 {
+		return repository.MinorChange
+	}
+	return repository.MajorChange
+}
+
+// prereleaseVersion appends the prerelease built from the pattern to the version.
+// The ReleaseToken is replaced with the number following the highest
+// existing prerelease of the same version, starting with 1.
+// The CommitToken is replaced with the given commit hash
+func prereleaseVersion(version semver.Version, pattern, commit string, existing []*semver.Version) (semver.Version, error) {
+	matcher := regexp.QuoteMeta(pattern)
+	matcher = strings.Replace(matcher, regexp.QuoteMeta(ReleaseToken), "(\\d+)", -1)
+	matcher = strings.Replace(matcher, regexp.QuoteMeta(CommitToken), "[0-9a-zA-Z-]+", -1)
+	matchRelease := regexp.MustCompile("^" + matcher + "$")
+	number := 0
+	for _, v := range existing {
+		if v.Major() != version.Major() || v.Minor() != version.Minor() || v.Patch() != version.Patch() {
+			continue
+		}
+		found := matchRelease.FindStringSubmatch(v.Prerelease())
+		if len(found) < 2 {
+			continue
+		}
+		n, err := strconv.Atoi(found[1])
+		if err == nil && n > number {
+			number = n
+		}
+	}
+	prerelease := strings.Replace(pattern, ReleaseToken, strconv.Itoa(number+1), -1)
+	prerelease = strings.Replace(prerelease, CommitToken, commit, -1)
+	return version.SetPrerelease(prerelease)
+}
+
+// envTokenPattern matches the EnvToken and captures the name of the variable
+var envTokenPattern = regexp.MustCompile("\\{ENV:(\\w+)\\}")
+
+// buildMetadata returns the build metadata built from the pattern.
+// The CommitToken is replaced with the given commit hash, the DateToken
+// with the date formatted as YYYYMMDD and every EnvToken with the value
+// of the environment variable, e.g. the build number provided by the CI
+func buildMetadata(pattern, commit string, date time.Time) (string, error) {
+	var missing []string
+	metadata := envTokenPattern.ReplaceAllStringFunc(pattern, func(token string) string {
+		name := envTokenPattern.FindStringSubmatch(token)[1]
+		value, ok := os.LookupEnv(name)
+		if !ok || value == "" {
+			missing = append(missing, name)
+		}
+		return value
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("build metadata: environment variable %s is not set", strings.Join(missing, ", "))
 	}
-	return latest.IncPatch()
+	metadata = strings.Replace(metadata, CommitToken, commit, -1)
+	metadata = strings.Replace(metadata, DateToken, date.UTC().Format("20060102"), -1)
+	return metadata, nil
 }
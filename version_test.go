@@ -5,6 +5,10 @@ import (
 	"os"
 	"path"
 	"testing"
+	"time"
+
+	"github.com/Masterminds/semver"
+	"github.com/moolen/asdf/repository"
 )
 
 func TestReadVersionFile(t *testing.T) {
@@ -29,4 +33,137 @@ func TestReadVersionFile(t *testing.T) {
 	if version.String() != "2.1.31" {
 		t.Fail()
 	}
+	ioutil.WriteFile(versionFile, []byte("not a version\n"), os.ModePerm)
+	_, err = readVersionFile(versionFile)
+	if err != errNoSemverVersion {
+		t.Fatalf("expected errNoSemverVersion, got %v", err)
+	}
+}
+
+func TestNextFinalVersion(t *testing.T) {
+	table := []struct {
+		latest string
+		change repository.Change
+		next   string
+	}{
+		{latest: "1.2.0", change: repository.MinorChange, next: "1.3.0"},
+		{latest: "1.3.0-rc.2", change: repository.PatchChange, next: "1.3.0"},
+		{latest: "1.3.0-rc.2", change: repository.MinorChange, next: "1.3.0"},
+		{latest: "1.3.0-rc.2", change: repository.NoChange, next: "1.3.0"},
+		{latest: "1.3.0-rc.2", change: repository.MajorChange, next: "2.0.0"},
+		{latest: "1.3.1-rc.1", change: repository.MinorChange, next: "1.4.0"},
+		{latest: "2.0.0-beta.1", change: repository.MajorChange, next: "2.0.0"},
+	}
+	for i, row := range table {
+		next := nextFinalVersion(semver.MustParse(row.latest), row.change)
+		if next.String() != row.next {
+			t.Fatalf("[%d] expected %s, got %s", i, row.next, next.String())
+		}
+	}
+}
+
+func TestPrereleaseVersion(t *testing.T) {
+	table := []struct {
+		version  string
+		pattern  string
+		existing []string
+		next     string
+	}{
+		{
+			version: "1.3.0",
+			pattern: "rc.{RELEASE_NUMBER}",
+			next:    "1.3.0-rc.1",
+		},
+		{
+			version:  "1.3.0",
+			pattern:  "rc.{RELEASE_NUMBER}",
+			existing: []string{"1.3.0-rc.1", "1.3.0-rc.10", "1.3.0-rc.2", "1.2.0-rc.11", "1.3.0-beta.12"},
+			next:     "1.3.0-rc.11",
+		},
+		{
+			version:  "1.3.0",
+			pattern:  "beta.{RELEASE_NUMBER}",
+			existing: []string{"1.3.0-rc.3"},
+			next:     "1.3.0-beta.1",
+		},
+		{
+			version: "1.3.0",
+			pattern: "dev.{COMMIT_SHA}",
+			next:    "1.3.0-dev.abcdef12",
+		},
+		{
+			version:  "1.3.0",
+			pattern:  "dev.{RELEASE_NUMBER}.{COMMIT_SHA}",
+			existing: []string{"1.3.0-dev.4.12345678"},
+			next:     "1.3.0-dev.5.abcdef12",
+		},
+	}
+	for i, row := range table {
+		var existing []*semver.Version
+		for _, v := range row.existing {
+			existing = append(existing, semver.MustParse(v))
+		}
+		next, err := prereleaseVersion(*semver.MustParse(row.version), row.pattern, "abcdef12", existing)
+		if err != nil {
+			t.Fatalf("[%d] unexpected error: %v", i, err)
+		}
+		if next.String() != row.next {
+			t.Fatalf("[%d] expected %s, got %s", i, row.next, next.String())
+		}
+	}
+}
+
+func TestBuildMetadata(t *testing.T) {
+	os.Setenv("ASDF_TEST_BUILD", "42")
+	defer os.Unsetenv("ASDF_TEST_BUILD")
+	date := time.Date(2026, 10, 18, 23, 0, 0, 0, time.UTC)
+	table := []struct {
+		pattern  string
+		metadata string
+		err      bool
+	}{
+		{pattern: "sha.{COMMIT_SHA}", metadata: "sha.abcdef12"},
+		{pattern: "{DATE}", metadata: "20261018"},
+		{pattern: "build.{ENV:ASDF_TEST_BUILD}", metadata: "build.42"},
+		{pattern: "build.{ENV:ASDF_TEST_BUILD}.sha.{COMMIT_SHA}", metadata: "build.42.sha.abcdef12"},
+		{pattern: "build.{ENV:ASDF_TEST_MISSING}", err: true},
+	}
+	for i, row := range table {
+		metadata, err := buildMetadata(row.pattern, "abcdef12", date)
+		if row.err {
+			if err == nil {
+				t.Fatalf("[%d] expected error, got %s", i, metadata)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("[%d] unexpected error: %v", i, err)
+		}
+		if metadata != row.metadata {
+			t.Fatalf("[%d] expected %s, got %s", i, row.metadata, metadata)
+		}
+	}
+}
+
+func TestInitialDevelopmentChange(t *testing.T) {
+	table := []struct {
+		latest string
+		change repository.Change
+		next   string
+	}{
+		{latest: "0.4.2", change: repository.MajorChange, next: "0.5.0"},
+		{latest: "0.4.2", change: repository.MinorChange, next: "0.4.3"},
+		{latest: "0.4.2", change: repository.PatchChange, next: "0.4.3"},
+		{latest: "0.4.2", change: repository.NoChange, next: "0.4.2"},
+		{latest: "0.5.0-rc.1", change: repository.MajorChange, next: "0.5.0"},
+		{latest: "1.4.2", change: repository.MajorChange, next: "2.0.0"},
+		{latest: "1.4.2", change: repository.MinorChange, next: "1.5.0"},
+	}
+	for i, row := range table {
+		latest := semver.MustParse(row.latest)
+		next := nextFinalVersion(latest, initialDevelopmentChange(latest, row.change))
+		if next.String() != row.next {
+			t.Fatalf("[%d] expected %s, got %s", i, row.next, next.String())
+		}
+	}
 }